@@ -0,0 +1,59 @@
+// Package errors extends the standard errors package with
+// annotation and root-cause inspection, so that a single error
+// value can accumulate context as it passes up the call stack
+// without losing its identity for callers that need to switch
+// on the original cause.
+package errors
+
+import (
+	"errors"
+	"fmt"
+)
+
+type wrapped struct {
+	msg  string
+	root error
+}
+
+func (w *wrapped) Error() string {
+	if w.msg == "" {
+		return w.root.Error()
+	}
+	return w.msg + ": " + w.root.Error()
+}
+
+// New returns an error that formats as the given text. It is a
+// drop-in replacement for the standard library's errors.New.
+func New(text string) error {
+	return errors.New(text)
+}
+
+// Wrap annotates err with args, formatted as with fmt.Sprint, and
+// returns a new error whose Root is the root of err (or err itself
+// if err was not already wrapped). Wrap of a nil error is nil.
+func Wrap(err error, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &wrapped{msg: fmt.Sprint(args...), root: Root(err)}
+}
+
+// Wrapf is like Wrap but formats its message with fmt.Sprintf.
+func Wrapf(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &wrapped{msg: fmt.Sprintf(format, args...), root: Root(err)}
+}
+
+// Root returns the original error at the bottom of a chain of
+// Wrap calls. If err was never wrapped, it returns err unchanged.
+func Root(err error) error {
+	for {
+		w, ok := err.(*wrapped)
+		if !ok {
+			return err
+		}
+		err = w.root
+	}
+}
@@ -0,0 +1,115 @@
+// Package bc defines the core data types of the federated chain:
+// transactions, blocks, and the assets they move.
+package bc
+
+import "crypto/sha256"
+
+// CurrentTransactionVersion is the tx version produced by this
+// version of the software.
+const CurrentTransactionVersion = 1
+
+// InvalidOutputIndex marks a TxInput that does not spend a prior
+// output, such as an issuance input.
+const InvalidOutputIndex uint32 = 0xffffffff
+
+// Hash is a 32-byte hash identifying a transaction or block.
+type Hash [32]byte
+
+// AssetID identifies a distinct asset type.
+type AssetID Hash
+
+// AssetAmount is a quantity of a specific asset.
+type AssetAmount struct {
+	AssetID AssetID
+	Amount  uint64
+}
+
+// Outpoint points to a previous transaction output.
+type Outpoint struct {
+	Hash  Hash
+	Index uint32
+}
+
+// TxInput is a single input to a transaction. An input whose
+// Previous.Index is InvalidOutputIndex is an issuance: it
+// introduces new units of AssetAmount.AssetID rather than
+// spending a prior output.
+type TxInput struct {
+	Previous        Outpoint
+	SignatureScript []byte
+	AssetAmount     AssetAmount
+	Vote            *VoteInput
+}
+
+// TxOutput is a single output of a transaction.
+type TxOutput struct {
+	AssetAmount
+	Script []byte
+	Vote   *VoteOutput
+}
+
+// TxData holds the contents of a transaction, before its hash
+// has been computed.
+type TxData struct {
+	Version uint32
+	Inputs  []*TxInput
+	Outputs []*TxOutput
+}
+
+// Tx pairs TxData with its cached hash.
+type Tx struct {
+	TxData
+	Hash Hash
+}
+
+// NewTx computes data's hash and returns the resulting Tx.
+func NewTx(data TxData) *Tx {
+	return &Tx{TxData: data, Hash: data.Hash()}
+}
+
+// Hash returns the hash identifying data. It is deterministic in
+// the fields that matter for identity (version, inputs, outputs)
+// but, unlike a production serialization, does not attempt to be
+// canonical across versions of this struct.
+func (d *TxData) Hash() Hash {
+	h := sha256.New()
+	writeUint32(h, d.Version)
+	for _, in := range d.Inputs {
+		h.Write(in.Previous.Hash[:])
+		writeUint32(h, in.Previous.Index)
+		h.Write(in.SignatureScript)
+		if in.Vote != nil {
+			h.Write(in.Vote.Candidate)
+			writeUint64(h, in.Vote.Deadline)
+		}
+	}
+	for _, out := range d.Outputs {
+		h.Write(out.AssetID[:])
+		writeUint64(h, out.Amount)
+		h.Write(out.Script)
+		if out.Vote != nil {
+			h.Write(out.Vote.Vote)
+			writeUint64(h, out.Vote.Deadline)
+		}
+	}
+	var hash Hash
+	copy(hash[:], h.Sum(nil))
+	return hash
+}
+
+func writeUint32(h interface{ Write([]byte) (int, error) }, v uint32) {
+	h.Write([]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
+}
+
+func writeUint64(h interface{ Write([]byte) (int, error) }, v uint64) {
+	h.Write([]byte{
+		byte(v >> 56), byte(v >> 48), byte(v >> 40), byte(v >> 32),
+		byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v),
+	})
+}
+
+// Block is an ordered set of transactions appended to the chain.
+type Block struct {
+	Transactions    []*Tx
+	SignatureScript []byte
+}
@@ -0,0 +1,45 @@
+package bc
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// EncodeTx serializes tx. The encoding is not a stable wire
+// format — it exists so tools like the conformance vector runner
+// have something concrete to persist and hex-encode — and should
+// not be used for anything that crosses a software version.
+func EncodeTx(tx *Tx) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(tx); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeTx is the inverse of EncodeTx.
+func DecodeTx(data []byte) (*Tx, error) {
+	var tx Tx
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// EncodeBlock serializes a block, on the same terms as EncodeTx.
+func EncodeBlock(b *Block) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(b); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeBlock is the inverse of EncodeBlock.
+func DecodeBlock(data []byte) (*Block, error) {
+	var b Block
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
@@ -0,0 +1,24 @@
+package bc
+
+// VoteInput marks a TxInput as casting a vote. In addition to
+// spending whatever Previous references — which must be an
+// ordinary, mature, script-authorized output, exactly as for a
+// plain transfer — it locks that value to Candidate until
+// Deadline. Ordinary transfer and issuance inputs leave this nil.
+type VoteInput struct {
+	Candidate []byte
+	Deadline  uint64
+}
+
+// VoteOutput marks a TxOutput as the result of a cast vote: Vote
+// and Deadline mirror the casting input's Candidate and Deadline.
+// The output's AssetAmount (carried by the embedding TxOutput)
+// must equal the spent input's, so that voting neither inflates
+// nor deflates the asset the way issuance does. Script continues
+// to authorize whoever eventually unvotes it, exactly as for an
+// ordinary TxOutput; unvoting is simply a plain spend of this
+// output once Deadline has passed.
+type VoteOutput struct {
+	Vote     []byte
+	Deadline uint64
+}
@@ -0,0 +1,160 @@
+// Package boltstore implements fedchain.Store on top of BoltDB, a
+// single-file embedded key-value store, for nodes that want
+// confirmed chain state to survive a restart without running a
+// separate database process.
+package boltstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"golang.org/x/net/context"
+
+	"github.com/boltdb/bolt"
+
+	"chain/errors"
+	"chain/fedchain/bc"
+)
+
+var (
+	utxosBucket   = []byte("utxos")
+	spentBucket   = []byte("spent")
+	appliedBucket = []byte("applied")
+)
+
+// Store is a fedchain.Store backed by a BoltDB file opened with
+// Open. Its zero value is not usable.
+type Store struct {
+	db   *bolt.DB
+	path string
+}
+
+// Open opens (creating if necessary) the BoltDB file at path and
+// returns a Store over it.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening bolt db")
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{utxosBucket, spentBucket, appliedBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "creating buckets")
+	}
+	return &Store{db: db, path: path}, nil
+}
+
+// Close releases the underlying BoltDB file.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func outpointKey(op bc.Outpoint) []byte {
+	key := make([]byte, 36)
+	copy(key, op.Hash[:])
+	binary.BigEndian.PutUint32(key[32:], op.Index)
+	return key
+}
+
+// ApplyTx records tx as confirmed within a single Bolt transaction.
+func (s *Store) ApplyTx(ctx context.Context, tx *bc.Tx, issued, voted map[bc.AssetID]uint64) error {
+	return s.db.Update(func(dbtx *bolt.Tx) error {
+		return applyTx(dbtx, tx)
+	})
+}
+
+// ApplyBlock applies every transaction in block within a single
+// Bolt transaction: either all of them land, or none do. FC prefers
+// this over repeated ApplyTx calls when the Store provides it; see
+// fedchain.BlockApplier.
+func (s *Store) ApplyBlock(ctx context.Context, block *bc.Block, issued, voted []map[bc.AssetID]uint64) error {
+	return s.db.Update(func(dbtx *bolt.Tx) error {
+		for _, tx := range block.Transactions {
+			if err := applyTx(dbtx, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func applyTx(dbtx *bolt.Tx, tx *bc.Tx) error {
+	applied := dbtx.Bucket(appliedBucket)
+	if applied.Get(tx.Hash[:]) != nil {
+		return nil
+	}
+
+	spent := dbtx.Bucket(spentBucket)
+	for _, in := range tx.Inputs {
+		if in.Previous.Index == bc.InvalidOutputIndex {
+			continue
+		}
+		if err := spent.Put(outpointKey(in.Previous), []byte{1}); err != nil {
+			return errors.Wrap(err, "marking spent ", in.Previous)
+		}
+	}
+
+	utxos := dbtx.Bucket(utxosBucket)
+	for i, out := range tx.Outputs {
+		data, err := json.Marshal(out)
+		if err != nil {
+			return errors.Wrap(err, "encoding output")
+		}
+		op := bc.Outpoint{Hash: tx.Hash, Index: uint32(i)}
+		if err := utxos.Put(outpointKey(op), data); err != nil {
+			return errors.Wrap(err, "storing output")
+		}
+	}
+
+	return applied.Put(tx.Hash[:], []byte{1})
+}
+
+// UTXO returns the confirmed, unspent output at op, if any.
+func (s *Store) UTXO(op bc.Outpoint) (*bc.TxOutput, bool) {
+	var out *bc.TxOutput
+	s.db.View(func(dbtx *bolt.Tx) error {
+		key := outpointKey(op)
+		if dbtx.Bucket(spentBucket).Get(key) != nil {
+			return nil
+		}
+		data := dbtx.Bucket(utxosBucket).Get(key)
+		if data == nil {
+			return nil
+		}
+		var o bc.TxOutput
+		if err := json.Unmarshal(data, &o); err != nil {
+			return nil
+		}
+		out = &o
+		return nil
+	})
+	return out, out != nil
+}
+
+// IsSpent reports whether op has already been consumed by a
+// confirmed transaction.
+func (s *Store) IsSpent(op bc.Outpoint) bool {
+	var spent bool
+	s.db.View(func(dbtx *bolt.Tx) error {
+		spent = dbtx.Bucket(spentBucket).Get(outpointKey(op)) != nil
+		return nil
+	})
+	return spent
+}
+
+// IsApplied reports whether tx has already been confirmed.
+func (s *Store) IsApplied(hash bc.Hash) bool {
+	var applied bool
+	s.db.View(func(dbtx *bolt.Tx) error {
+		applied = dbtx.Bucket(appliedBucket).Get(hash[:]) != nil
+		return nil
+	})
+	return applied
+}
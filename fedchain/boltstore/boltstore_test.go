@@ -0,0 +1,33 @@
+package boltstore
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+
+	"chain/fedchain"
+	"chain/fedchain/storetest"
+	"chain/testutil"
+)
+
+// TestStore runs the fedchain.Store acceptance suite against a
+// fresh BoltDB file per subtest.
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boltstore")
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	defer os.RemoveAll(dir)
+
+	n := 0
+	storetest.Run(t, func() fedchain.Store {
+		n++
+		path := dir + "/" + strconv.Itoa(n) + ".db"
+		store, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	})
+}
@@ -0,0 +1,46 @@
+package boltstore
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	"golang.org/x/net/context"
+
+	"github.com/boltdb/bolt"
+
+	"chain/errors"
+)
+
+// Snapshot returns the entire underlying BoltDB file, so a new node
+// can bootstrap its Store from a single blob instead of replaying
+// every block from genesis.
+func (s *Store) Snapshot(ctx context.Context) ([]byte, error) {
+	var buf bytes.Buffer
+	err := s.db.View(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(&buf)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "writing snapshot")
+	}
+	return buf.Bytes(), nil
+}
+
+// Restore replaces the Store's entire confirmed state with data, a
+// blob produced by Snapshot, by closing the current database file,
+// overwriting it, and reopening. It is meant for bootstrapping a
+// new node before any other call has touched the Store.
+func (s *Store) Restore(ctx context.Context, data []byte) error {
+	if err := s.db.Close(); err != nil {
+		return errors.Wrap(err, "closing bolt db")
+	}
+	if err := ioutil.WriteFile(s.path, data, 0600); err != nil {
+		return errors.Wrap(err, "writing snapshot to disk")
+	}
+	db, err := bolt.Open(s.path, 0600, nil)
+	if err != nil {
+		return errors.Wrap(err, "reopening bolt db")
+	}
+	s.db = db
+	return nil
+}
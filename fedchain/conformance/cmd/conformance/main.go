@@ -0,0 +1,84 @@
+// Command conformance runs (or regenerates) a corpus of fedchain
+// conformance vectors outside of `go test`, producing a JUnit-style
+// report so it can plug into CI systems that don't speak Go test
+// output.
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"chain/fedchain/conformance"
+)
+
+type junitCase struct {
+	Name    string `xml:"name,attr"`
+	Failure *struct {
+		Message string `xml:",chardata"`
+	} `xml:"failure,omitempty"`
+}
+
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+func main() {
+	dir := flag.String("dir", "testdata", "directory of *.json conformance vectors")
+	regen := flag.Bool("regen", false, "rewrite each vector's expected fields instead of checking them")
+	out := flag.String("out", "", "JUnit XML report path (default: stdout)")
+	flag.Parse()
+
+	vectors, err := conformance.Load(*dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *regen {
+		for _, v := range vectors {
+			if err := conformance.Regen(*dir, v); err != nil {
+				log.Fatal(err)
+			}
+		}
+		fmt.Printf("regenerated %d vectors in %s\n", len(vectors), *dir)
+		return
+	}
+
+	suite := junitSuite{Name: "fedchain/conformance"}
+	for _, v := range vectors {
+		suite.Tests++
+		c := junitCase{Name: v.Name}
+		if msg := conformance.Check(v); msg != "" {
+			suite.Failures++
+			c.Failure = &struct {
+				Message string `xml:",chardata"`
+			}{Message: msg}
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(data)
+		os.Stdout.WriteString("\n")
+	} else {
+		if err := ioutil.WriteFile(*out, data, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if suite.Failures > 0 {
+		os.Exit(1)
+	}
+}
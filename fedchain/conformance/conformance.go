@@ -0,0 +1,350 @@
+package conformance
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"chain/errors"
+	"chain/fedchain"
+	"chain/fedchain/bc"
+	"chain/fedchain/memstore"
+)
+
+// recordingStore wraps a MemStore and remembers the issued/voted
+// maps passed to the most recent ApplyTx call, so a vector can
+// assert on them without memstore itself needing to track totals.
+type recordingStore struct {
+	memstore.MemStore
+	lastIssued map[bc.AssetID]uint64
+	lastVoted  map[bc.AssetID]uint64
+}
+
+func (s *recordingStore) ApplyTx(ctx context.Context, tx *bc.Tx, issued, voted map[bc.AssetID]uint64) error {
+	err := s.MemStore.ApplyTx(ctx, tx, issued, voted)
+	s.lastIssued = issued
+	s.lastVoted = voted
+	return err
+}
+
+// Load reads every *.json file in dir as a Vector, sorted by file
+// name so a corpus runs in a stable, reviewable order.
+func Load(dir string) ([]Vector, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	vectors := make([]Vector, 0, len(paths))
+	for _, p := range paths {
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading ", p)
+		}
+		var v Vector
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, errors.Wrap(err, "parsing ", p)
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors, nil
+}
+
+// Run executes every vector in dir as a subtest of t.
+func Run(t *testing.T, dir string) {
+	vectors, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		v := v
+		t.Run(v.Name, func(t *testing.T) {
+			if msg := Check(v); msg != "" {
+				t.Fatal(msg)
+			}
+		})
+	}
+}
+
+// Check runs v and returns a description of the first mismatch
+// between its expected and actual outcome, or "" if v passes. It is
+// the non-testing.T entry point Run and the standalone conformance
+// binary both build on.
+func Check(v Vector) string {
+	return runVector(v).err
+}
+
+// Regen re-runs v's tx/block against its seed and rewrites its
+// expected fields (WantErrRoot, UTXOAdded, UTXORemoved, IssuedDelta,
+// VotedDelta) to match, leaving Name, Seed, Tx, and Block — the
+// vector's inputs — untouched. For a Block vector, UTXOAdded and
+// UTXORemoved are left empty. It overwrites the vector's source
+// file, which it locates as "<dir>/<v.Name>.json".
+func Regen(dir string, v Vector) error {
+	fresh, err := regenVector(v)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(fresh, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, v.Name+".json")
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// result is the outcome of running a single vector, kept separate
+// from *testing.T so the standalone binary can report it too.
+type result struct {
+	name string
+	err  string // non-empty means failed; the message is the failure
+}
+
+// execution is what happened when a vector's seed, then its tx or
+// block, were driven through a fresh FC.
+type execution struct {
+	store    *recordingStore
+	tx       *bc.Tx // the decoded vector Tx, or nil for a Block vector
+	applyErr error  // from applying Tx (after confirming) or Block
+}
+
+// execute runs v's seed and tx-or-block against a fresh FC. setupErr
+// is non-nil only for malformed input (bad hex, neither Tx nor
+// Block set) — a problem with the vector itself, not an outcome
+// FC produced.
+func execute(v Vector) (exec execution, setupErr error) {
+	ctx := context.Background()
+	store := &recordingStore{MemStore: *memstore.New()}
+	fc, err := fedchain.New(ctx, store, nil)
+	if err != nil {
+		return execution{}, err
+	}
+	exec.store = store
+
+	for _, s := range v.Seed {
+		tx, err := decodeTxHex(s)
+		if err != nil {
+			return execution{}, errors.Wrap(err, "decoding seed tx")
+		}
+		if err := confirmOne(ctx, fc, tx); err != nil {
+			return execution{}, errors.Wrap(err, "confirming seed tx")
+		}
+	}
+
+	switch {
+	case v.Tx != "":
+		tx, err := decodeTxHex(v.Tx)
+		if err != nil {
+			return execution{}, errors.Wrap(err, "decoding tx")
+		}
+		exec.tx = tx
+		exec.applyErr = fc.AddTx(ctx, tx)
+		if exec.applyErr == nil {
+			exec.applyErr = confirmPending(ctx, fc)
+		}
+	case v.Block != "":
+		data, err := hex.DecodeString(v.Block)
+		if err != nil {
+			return execution{}, errors.Wrap(err, "decoding block")
+		}
+		block, err := bc.DecodeBlock(data)
+		if err != nil {
+			return execution{}, errors.Wrap(err, "decoding block")
+		}
+		exec.applyErr = fc.AddBlock(ctx, block)
+	default:
+		return execution{}, fmt.Errorf("vector specifies neither tx nor block")
+	}
+	return exec, nil
+}
+
+func runVector(v Vector) (res result) {
+	res.name = v.Name
+
+	exec, err := execute(v)
+	if err != nil {
+		res.err = err.Error()
+		return res
+	}
+
+	if v.WantErrRoot != "" {
+		if exec.applyErr == nil {
+			res.err = fmt.Sprintf("got no error, want root %q", v.WantErrRoot)
+		} else if got := errors.Root(exec.applyErr).Error(); got != v.WantErrRoot {
+			res.err = fmt.Sprintf("got error root %q, want %q", got, v.WantErrRoot)
+		}
+		return res
+	}
+	if exec.applyErr != nil {
+		res.err = fmt.Sprintf("unexpected error: %s", exec.applyErr)
+		return res
+	}
+
+	if msg := checkUTXOs(exec.store, v); msg != "" {
+		res.err = msg
+		return res
+	}
+	if msg := checkDelta("issued", exec.store.lastIssued, v.IssuedDelta); msg != "" {
+		res.err = msg
+		return res
+	}
+	if msg := checkDelta("voted", exec.store.lastVoted, v.VotedDelta); msg != "" {
+		res.err = msg
+		return res
+	}
+	return res
+}
+
+// regenVector re-runs v and returns a copy with its expected
+// fields recomputed from what actually happened.
+func regenVector(v Vector) (Vector, error) {
+	exec, err := execute(v)
+	if err != nil {
+		return Vector{}, err
+	}
+
+	fresh := Vector{Name: v.Name, Seed: v.Seed, Tx: v.Tx, Block: v.Block}
+	if exec.applyErr != nil {
+		fresh.WantErrRoot = errors.Root(exec.applyErr).Error()
+		return fresh, nil
+	}
+
+	if exec.tx != nil {
+		for i := range exec.tx.Outputs {
+			fresh.UTXOAdded = append(fresh.UTXOAdded, formatOutpoint(bc.Outpoint{Hash: exec.tx.Hash, Index: uint32(i)}))
+		}
+		for _, in := range exec.tx.Inputs {
+			if in.Previous.Index == bc.InvalidOutputIndex {
+				continue
+			}
+			fresh.UTXORemoved = append(fresh.UTXORemoved, formatOutpoint(in.Previous))
+		}
+	}
+	if len(exec.store.lastIssued) > 0 {
+		fresh.IssuedDelta = map[string]uint64{}
+		for id, amount := range exec.store.lastIssued {
+			fresh.IssuedDelta[hex.EncodeToString(id[:])] = amount
+		}
+	}
+	if len(exec.store.lastVoted) > 0 {
+		fresh.VotedDelta = map[string]uint64{}
+		for id, amount := range exec.store.lastVoted {
+			fresh.VotedDelta[hex.EncodeToString(id[:])] = amount
+		}
+	}
+	return fresh, nil
+}
+
+// confirmOne adds tx to fc and immediately lands a block containing
+// just it.
+func confirmOne(ctx context.Context, fc *fedchain.FC, tx *bc.Tx) error {
+	if err := fc.AddTx(ctx, tx); err != nil {
+		return err
+	}
+	return confirmPending(ctx, fc)
+}
+
+// confirmPending assembles and lands a block from whatever is
+// currently pending.
+func confirmPending(ctx context.Context, fc *fedchain.FC) error {
+	block, _, err := fc.GenerateBlock(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	return fc.AddBlock(ctx, block)
+}
+
+func checkUTXOs(store *recordingStore, v Vector) string {
+	for _, s := range v.UTXOAdded {
+		op, err := parseOutpoint(s)
+		if err != nil {
+			return err.Error()
+		}
+		if _, ok := store.UTXO(op); !ok {
+			return fmt.Sprintf("utxo_added %s not found in store", s)
+		}
+	}
+	for _, s := range v.UTXORemoved {
+		op, err := parseOutpoint(s)
+		if err != nil {
+			return err.Error()
+		}
+		if !store.IsSpent(op) {
+			return fmt.Sprintf("utxo_removed %s not marked spent in store", s)
+		}
+	}
+	return ""
+}
+
+func checkDelta(label string, got map[bc.AssetID]uint64, want map[string]uint64) string {
+	if len(want) == 0 && len(got) == 0 {
+		return ""
+	}
+	gotHex := make(map[string]uint64, len(got))
+	for id, amount := range got {
+		gotHex[hex.EncodeToString(id[:])] = amount
+	}
+	for assetIDHex, wantAmount := range want {
+		gotAmount, ok := gotHex[assetIDHex]
+		if !ok || gotAmount != wantAmount {
+			return fmt.Sprintf("%s[%s] = %d, want %d", label, assetIDHex, gotAmount, wantAmount)
+		}
+	}
+	for assetIDHex := range gotHex {
+		if _, ok := want[assetIDHex]; !ok {
+			return fmt.Sprintf("%s[%s] = %d, want no entry", label, assetIDHex, gotHex[assetIDHex])
+		}
+	}
+	return ""
+}
+
+func decodeTxHex(s string) (*bc.Tx, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return bc.DecodeTx(data)
+}
+
+func encodeTxHex(tx *bc.Tx) (string, error) {
+	data, err := bc.EncodeTx(tx)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// parseOutpoint parses a "<tx-hash-hex>:<index>" outpoint string.
+func parseOutpoint(s string) (bc.Outpoint, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return bc.Outpoint{}, fmt.Errorf("malformed outpoint %q", s)
+	}
+	hashBytes, err := hex.DecodeString(parts[0])
+	if err != nil || len(hashBytes) != 32 {
+		return bc.Outpoint{}, fmt.Errorf("malformed outpoint hash %q", parts[0])
+	}
+	index, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return bc.Outpoint{}, fmt.Errorf("malformed outpoint index %q", parts[1])
+	}
+	var op bc.Outpoint
+	copy(op.Hash[:], hashBytes)
+	op.Index = uint32(index)
+	return op, nil
+}
+
+// formatOutpoint is the inverse of parseOutpoint.
+func formatOutpoint(op bc.Outpoint) string {
+	return fmt.Sprintf("%s:%d", hex.EncodeToString(op.Hash[:]), op.Index)
+}
@@ -0,0 +1,50 @@
+package conformance
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"chain/fedchain/bc"
+	"chain/fedchain/fedtest"
+	"chain/testutil"
+)
+
+// TestConformance freezes a handful of fedtest-built transactions
+// as vectors, then runs them back through Run, exercising the same
+// path a committed testdata corpus would.
+func TestConformance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conformance")
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	defer os.RemoveAll(dir)
+
+	issueTx, _, dest := fedtest.Issue(t, nil, nil, 10)
+	writeVector(t, dir, "issue", nil, issueTx)
+
+	transferTx := fedtest.Transfer(t, fedtest.StateOut(issueTx, 0), dest, fedtest.Dest(t))
+	writeVector(t, dir, "transfer", []*bc.Tx{issueTx}, transferTx)
+
+	doubleSpend := fedtest.Transfer(t, fedtest.StateOut(issueTx, 0), dest, fedtest.Dest(t))
+	writeVector(t, dir, "double-spend", []*bc.Tx{issueTx, transferTx}, doubleSpend)
+
+	Run(t, dir)
+}
+
+func writeVector(t *testing.T, dir, name string, seed []*bc.Tx, tx *bc.Tx) {
+	v, err := Generate(name, seed, tx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, name+".json"), data, 0644)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+}
@@ -0,0 +1,77 @@
+package conformance
+
+import (
+	"encoding/hex"
+
+	"golang.org/x/net/context"
+
+	"chain/errors"
+	"chain/fedchain"
+	"chain/fedchain/bc"
+	"chain/fedchain/memstore"
+)
+
+// Generate drives tx through a fresh FC, confirming seed first (in
+// order — typically the fedtest.Issue/Transfer outputs tx spends),
+// and returns the resulting Vector. Contributors use this to freeze
+// the current, presumed-correct behavior of a fedtest-built
+// transaction as a vector; write the result to testdata as JSON.
+func Generate(name string, seed []*bc.Tx, tx *bc.Tx) (Vector, error) {
+	ctx := context.Background()
+	store := &recordingStore{MemStore: *memstore.New()}
+	fc, err := fedchain.New(ctx, store, nil)
+	if err != nil {
+		return Vector{}, err
+	}
+
+	v := Vector{Name: name}
+	for _, s := range seed {
+		encoded, err := encodeTxHex(s)
+		if err != nil {
+			return Vector{}, err
+		}
+		v.Seed = append(v.Seed, encoded)
+		if err := confirmOne(ctx, fc, s); err != nil {
+			return Vector{}, errors.Wrap(err, "confirming seed tx")
+		}
+	}
+
+	encoded, err := encodeTxHex(tx)
+	if err != nil {
+		return Vector{}, err
+	}
+	v.Tx = encoded
+
+	applyErr := fc.AddTx(ctx, tx)
+	if applyErr == nil {
+		applyErr = confirmPending(ctx, fc)
+	}
+	if applyErr != nil {
+		v.WantErrRoot = errors.Root(applyErr).Error()
+		return v, nil
+	}
+
+	for i := range tx.Outputs {
+		v.UTXOAdded = append(v.UTXOAdded, formatOutpoint(bc.Outpoint{Hash: tx.Hash, Index: uint32(i)}))
+	}
+	for _, in := range tx.Inputs {
+		if in.Previous.Index == bc.InvalidOutputIndex {
+			continue
+		}
+		v.UTXORemoved = append(v.UTXORemoved, formatOutpoint(in.Previous))
+	}
+
+	if len(store.lastIssued) > 0 {
+		v.IssuedDelta = map[string]uint64{}
+		for id, amount := range store.lastIssued {
+			v.IssuedDelta[hex.EncodeToString(id[:])] = amount
+		}
+	}
+	if len(store.lastVoted) > 0 {
+		v.VotedDelta = map[string]uint64{}
+		for id, amount := range store.lastVoted {
+			v.VotedDelta[hex.EncodeToString(id[:])] = amount
+		}
+	}
+	return v, nil
+}
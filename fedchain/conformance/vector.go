@@ -0,0 +1,43 @@
+// Package conformance drives a corpus of JSON test vectors through
+// a freshly constructed fedchain.FC, so that the behavior of
+// FC.AddTx and FC.AddBlock can be frozen and checked against
+// alternative implementations, not just the one in this tree.
+package conformance
+
+// Vector is a single (pre-state, tx-or-block, expected-post-state)
+// case. Exactly one of Tx and Block is set, describing what gets
+// passed to FC.AddTx (followed by assembling and landing a block
+// containing just that tx) or FC.AddBlock, respectively.
+//
+// Hashes, asset IDs, and scripts are hex strings; Hash and AssetID
+// are both 32 bytes, so a 64-character string.
+type Vector struct {
+	Name string `json:"name"`
+
+	// Seed lists transactions to confirm, in order, against an
+	// empty store before Tx or Block is applied. Typically these
+	// are issuances that fund the UTXOs Tx or Block spends.
+	Seed []string `json:"seed,omitempty"`
+
+	Tx    string `json:"tx,omitempty"`
+	Block string `json:"block,omitempty"`
+
+	// WantErrRoot, if set, is the Error() string of the root cause
+	// FC.AddTx/AddBlock must return. When set, the fields below are
+	// not checked.
+	WantErrRoot string `json:"want_err_root,omitempty"`
+
+	// UTXOAdded and UTXORemoved describe the confirmed UTXO set's
+	// change: UTXOAdded is Tx's (or each of Block's txs') outputs,
+	// UTXORemoved is the outpoints its non-issuance inputs spend,
+	// both as "<tx-hash-hex>:<index>" outpoint strings.
+	UTXOAdded   []string `json:"utxo_added,omitempty"`
+	UTXORemoved []string `json:"utxo_removed,omitempty"`
+
+	// IssuedDelta and VotedDelta are the issued/voted maps FC
+	// computed, keyed by asset ID hex. Only the maps passed with the
+	// last ApplyTx call are recorded; for a Block vector that is the
+	// block's last transaction.
+	IssuedDelta map[string]uint64 `json:"issued_delta,omitempty"`
+	VotedDelta  map[string]uint64 `json:"voted_delta,omitempty"`
+}
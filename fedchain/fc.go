@@ -0,0 +1,254 @@
+// Package fedchain implements the federated chain: admission of
+// transactions, assembly of blocks, and application of confirmed
+// blocks to a Store.
+package fedchain
+
+import (
+	"bytes"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"chain/errors"
+	"chain/fedchain/bc"
+	"chain/fedchain/txpool"
+	"chain/fedchain/txscript"
+)
+
+// ErrTxRejected is the root of errors returned when a transaction
+// fails validation, whether because it conflicts with a confirmed
+// output or with another pending transaction.
+var ErrTxRejected = errors.New("transaction rejected")
+
+// Store is the durable backing for confirmed chain state. FC calls
+// ApplyTx once per confirmed transaction, in block order, as blocks
+// are added (or ApplyBlock once per block, for a Store that also
+// implements BlockApplier); everything prior to confirmation lives
+// only in FC's in-memory pool.
+type Store interface {
+	// ApplyTx records tx as confirmed, consuming the outputs it
+	// spends and creating the outputs it defines. issued and voted
+	// carry the issuance and voting accounting FC computed for tx
+	// at admission time, keyed by asset, so Store implementations
+	// don't need to recompute them.
+	ApplyTx(ctx context.Context, tx *bc.Tx, issued, voted map[bc.AssetID]uint64) error
+
+	// UTXO returns the confirmed output at op, if unspent.
+	UTXO(op bc.Outpoint) (*bc.TxOutput, bool)
+
+	// IsSpent reports whether op has already been consumed by a
+	// confirmed transaction.
+	IsSpent(op bc.Outpoint) bool
+}
+
+// BlockApplier is a Store extension for applying a whole block as a
+// single all-or-nothing unit — a real database transaction, for a
+// database-backed Store — rather than one ApplyTx call per
+// transaction with no atomicity guarantee across them. AddBlock
+// uses it when the Store provides it, and falls back to calling
+// ApplyTx once per transaction otherwise.
+type BlockApplier interface {
+	ApplyBlock(ctx context.Context, block *bc.Block, issued, voted []map[bc.AssetID]uint64) error
+}
+
+// FC mediates between pending transactions and confirmed chain
+// state held in a Store.
+type FC struct {
+	store Store
+	pool  *txpool.Pool
+}
+
+// New returns an FC backed by store. opts configures the pending
+// pool's eviction policy; the zero value uses the package defaults.
+func New(ctx context.Context, store Store, opts *txpool.Options) (*FC, error) {
+	var popts txpool.Options
+	if opts != nil {
+		popts = *opts
+	}
+	return &FC{
+		store: store,
+		pool:  txpool.New(popts),
+	}, nil
+}
+
+// AddTx validates tx against the current UTXO view (confirmed
+// outputs plus whatever the pending pool has already admitted) and,
+// if it is valid, enqueues it into the pool. AddTx does not apply
+// tx to the Store; that happens only once tx is confirmed in a
+// block passed to AddBlock. Adding a transaction that has already
+// been confirmed, or that is already pending, is a no-op.
+func (fc *FC) AddTx(ctx context.Context, tx *bc.Tx) error {
+	issued, voted, err := fc.validate(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if issued == nil && voted == nil {
+		// Already confirmed; nothing left to do.
+		return nil
+	}
+	err = fc.pool.Add(ctx, tx, issued, voted)
+	if errors.Root(err) == txpool.ErrConflict {
+		return errors.Wrap(ErrTxRejected, err)
+	}
+	return err
+}
+
+// validate checks tx's inputs against confirmed and pending state
+// and computes its issuance and voting accounting. It returns nil
+// maps (and no error) if tx is already confirmed.
+func (fc *FC) validate(ctx context.Context, tx *bc.Tx) (issued, voted map[bc.AssetID]uint64, err error) {
+	if applied, ok := fc.store.(interface {
+		IsApplied(bc.Hash) bool
+	}); ok && applied.IsApplied(tx.Hash) {
+		return nil, nil, nil
+	}
+
+	hasIssuance := false
+	issuedAssets := map[bc.AssetID]bool{}
+	assets := map[bc.AssetID]bool{}
+	spent := map[bc.AssetID]uint64{}
+	votedSpent := map[bc.AssetID]uint64{}
+	outputs := map[bc.AssetID]uint64{}
+	votedOutputs := map[bc.AssetID]uint64{}
+	voteLocks := map[bc.AssetID]*bc.VoteInput{}
+	now := uint64(time.Now().Unix())
+
+	for _, in := range tx.Inputs {
+		if in.Previous.Index == bc.InvalidOutputIndex {
+			hasIssuance = true
+			issuedAssets[in.AssetAmount.AssetID] = true
+			assets[in.AssetAmount.AssetID] = true
+			continue
+		}
+		prevOut, ok := fc.store.UTXO(in.Previous)
+		if !ok {
+			if pending := fc.pool.Get(in.Previous.Hash); pending != nil {
+				idx := int(in.Previous.Index)
+				if idx >= 0 && idx < len(pending.Tx.Outputs) {
+					prevOut = pending.Tx.Outputs[idx]
+					ok = true
+				}
+			}
+		}
+		if !ok || fc.store.IsSpent(in.Previous) {
+			return nil, nil, errors.Wrap(ErrTxRejected, "no such unspent output ", in.Previous)
+		}
+		if !txscript.VerifyScript(in.SignatureScript, prevOut.Script) {
+			return nil, nil, errors.Wrap(ErrTxRejected, "signature script does not authorize spend of ", in.Previous)
+		}
+		if prevOut.Vote != nil && now < prevOut.Vote.Deadline {
+			return nil, nil, errors.Wrap(ErrTxRejected, "vote lock on ", in.Previous, " has not reached its deadline")
+		}
+		assets[prevOut.AssetID] = true
+		spent[prevOut.AssetID] += prevOut.Amount
+		if in.Vote != nil {
+			votedSpent[prevOut.AssetID] += prevOut.Amount
+			if lock, ok := voteLocks[prevOut.AssetID]; ok && !sameVote(lock, in.Vote) {
+				return nil, nil, errors.Wrap(ErrTxRejected, "conflicting vote inputs for ", prevOut.AssetID)
+			}
+			voteLocks[prevOut.AssetID] = in.Vote
+		}
+	}
+
+	for _, out := range tx.Outputs {
+		assets[out.AssetID] = true
+		outputs[out.AssetID] += out.Amount
+		if out.Vote != nil {
+			votedOutputs[out.AssetID] += out.Amount
+			lock, ok := voteLocks[out.AssetID]
+			if !ok || !bytes.Equal(lock.Candidate, out.Vote.Vote) || lock.Deadline != out.Vote.Deadline {
+				return nil, nil, errors.Wrap(ErrTxRejected, "vote output for ", out.AssetID, " does not match the casting input's candidate and deadline")
+			}
+		}
+	}
+
+	// Conservation of value: every asset not freshly issued in this
+	// tx must have its spent amount exactly reappear in outputs. An
+	// issued asset may create more than it spent (that excess is
+	// what issuance means), but never less, since outputs[assetID] -
+	// spent[assetID] below would underflow.
+	for assetID := range assets {
+		if issuedAssets[assetID] {
+			if outputs[assetID] < spent[assetID] {
+				return nil, nil, errors.Wrap(ErrTxRejected, "issuance of ", assetID, " spends more than it issues and outputs")
+			}
+			continue
+		}
+		if outputs[assetID] != spent[assetID] {
+			return nil, nil, errors.Wrap(ErrTxRejected, "asset ", assetID, " inputs and outputs do not balance")
+		}
+	}
+
+	issued = map[bc.AssetID]uint64{}
+	if hasIssuance {
+		for assetID := range assets {
+			issued[assetID] = outputs[assetID] - spent[assetID]
+		}
+	}
+
+	voted = map[bc.AssetID]uint64{}
+	for assetID, amount := range votedSpent {
+		if votedOutputs[assetID] != amount {
+			return nil, nil, errors.Wrap(ErrTxRejected, "vote for asset ", assetID, " does not preserve amount")
+		}
+		voted[assetID] = amount
+	}
+
+	return issued, voted, nil
+}
+
+// sameVote reports whether a and b lock the same candidate until
+// the same deadline.
+func sameVote(a *bc.VoteInput, b *bc.VoteInput) bool {
+	return bytes.Equal(a.Candidate, b.Candidate) && a.Deadline == b.Deadline
+}
+
+// AddBlock applies block's transactions to the Store, in order,
+// then reconciles the pending pool against it: confirmed
+// transactions are removed, and any pending transaction that
+// conflicts with an output the block consumed is evicted.
+func (fc *FC) AddBlock(ctx context.Context, block *bc.Block) error {
+	issued := make([]map[bc.AssetID]uint64, len(block.Transactions))
+	voted := make([]map[bc.AssetID]uint64, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		issued[i] = map[bc.AssetID]uint64{}
+		voted[i] = map[bc.AssetID]uint64{}
+		if entry := fc.pool.Get(tx.Hash); entry != nil {
+			issued[i] = entry.Issued
+			voted[i] = entry.Voted
+		}
+	}
+
+	if applier, ok := fc.store.(BlockApplier); ok {
+		if err := applier.ApplyBlock(ctx, block, issued, voted); err != nil {
+			return errors.Wrap(err, "applying confirmed block")
+		}
+	} else {
+		for i, tx := range block.Transactions {
+			if err := fc.store.ApplyTx(ctx, tx, issued[i], voted[i]); err != nil {
+				return errors.Wrap(err, "applying confirmed tx ", tx.Hash)
+			}
+		}
+	}
+
+	fc.pool.RemoveConfirmed(block)
+	return nil
+}
+
+// GenerateBlock assembles a new block from the pending pool,
+// honoring dependency order (a transaction never precedes one it
+// spends from) and maxBlockTxs as a size cap. It does not apply the
+// block or mutate the pool; callers pass the result to AddBlock.
+func (fc *FC) GenerateBlock(ctx context.Context, at time.Time) (*bc.Block, *bc.TxData, error) {
+	const maxBlockTxs = 10000
+
+	pending := fc.pool.Dump()
+	block := &bc.Block{}
+	for _, entry := range pending {
+		if len(block.Transactions) >= maxBlockTxs {
+			break
+		}
+		block.Transactions = append(block.Transactions, entry.Tx)
+	}
+	return block, nil, nil
+}
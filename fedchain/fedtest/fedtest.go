@@ -0,0 +1,167 @@
+// Package fedtest provides helpers for constructing signed
+// transactions in tests, without pulling in a real wallet or key
+// management stack.
+package fedtest
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"chain/fedchain/bc"
+	"chain/fedchain/txscript"
+)
+
+// assetFixture stands in for an issuer: something that can
+// authorize issuance of a particular AssetID.
+type assetFixture struct {
+	AssetID bc.AssetID
+}
+
+// NewAsset returns a freshly minted asset fixture.
+func NewAsset(t *testing.T) *assetFixture {
+	a := &assetFixture{}
+	if _, err := rand.Read(a.AssetID[:]); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// Sign authorizes tx's input at index as an issuance of a.AssetID.
+// prev is unused for issuance inputs (there is no prior output to
+// authorize spending of) and is accepted only for symmetry with
+// destFixture.Sign.
+func (a *assetFixture) Sign(t *testing.T, tx *bc.TxData, index int, prev bc.AssetAmount) {
+	in := tx.Inputs[index]
+	in.AssetAmount = bc.AssetAmount{AssetID: a.AssetID}
+	in.SignatureScript = []byte{txscript.OP_TRUE}
+}
+
+// destFixture stands in for an output script's owner: something
+// that can authorize spending of outputs locked to its script.
+type destFixture struct {
+	PKScript []byte
+}
+
+// NewDest returns a freshly minted dest fixture.
+func NewDest(t *testing.T) *destFixture {
+	script := make([]byte, 20)
+	if _, err := rand.Read(script); err != nil {
+		t.Fatal(err)
+	}
+	return &destFixture{PKScript: script}
+}
+
+// Sign authorizes tx's input at index to spend a previous output
+// carrying prev.
+func (d *destFixture) Sign(t *testing.T, tx *bc.TxData, index int, prev bc.AssetAmount) {
+	tx.Inputs[index].SignatureScript = []byte{txscript.OP_TRUE}
+}
+
+// Asset is the package-level convenience constructor for an asset
+// fixture, mirroring Dest below; most callers use these instead of
+// NewAsset/NewDest directly.
+func Asset(t *testing.T) *assetFixture { return NewAsset(t) }
+
+// Dest is the package-level convenience constructor for a dest
+// fixture.
+func Dest(t *testing.T) *destFixture { return NewDest(t) }
+
+// Issue returns a signed issuance transaction for amount units of
+// asset, paid to dest. A nil asset or dest is replaced with a
+// freshly minted one, and the (possibly freshly minted) asset and
+// dest are returned alongside the transaction so callers can reuse
+// them.
+func Issue(t *testing.T, asset *assetFixture, dest *destFixture, amount uint64) (*bc.Tx, *assetFixture, *destFixture) {
+	if asset == nil {
+		asset = NewAsset(t)
+	}
+	if dest == nil {
+		dest = NewDest(t)
+	}
+	data := &bc.TxData{
+		Version: bc.CurrentTransactionVersion,
+		Inputs: []*bc.TxInput{
+			{Previous: bc.Outpoint{Index: bc.InvalidOutputIndex}},
+		},
+		Outputs: []*bc.TxOutput{
+			{
+				Script:      dest.PKScript,
+				AssetAmount: bc.AssetAmount{AssetID: asset.AssetID, Amount: amount},
+			},
+		},
+	}
+	asset.Sign(t, data, 0, bc.AssetAmount{})
+	return bc.NewTx(*data), asset, dest
+}
+
+// Output references one output of a transaction, along with the
+// information needed to spend it: its Outpoint and the
+// AssetAmount it carries.
+type Output struct {
+	Outpoint bc.Outpoint
+	bc.AssetAmount
+	Script []byte
+}
+
+// StateOut returns the Output for tx's output at index.
+func StateOut(tx *bc.Tx, index int) *Output {
+	out := tx.Outputs[index]
+	return &Output{
+		Outpoint:    bc.Outpoint{Hash: tx.Hash, Index: uint32(index)},
+		AssetAmount: out.AssetAmount,
+		Script:      out.Script,
+	}
+}
+
+// Transfer returns a signed transaction spending in, authorized by
+// from, and paying the same asset and amount to to.
+func Transfer(t *testing.T, in *Output, from, to *destFixture) *bc.Tx {
+	data := &bc.TxData{
+		Version: bc.CurrentTransactionVersion,
+		Inputs: []*bc.TxInput{
+			{Previous: in.Outpoint},
+		},
+		Outputs: []*bc.TxOutput{
+			{
+				Script:      to.PKScript,
+				AssetAmount: in.AssetAmount,
+			},
+		},
+	}
+	from.Sign(t, data, 0, in.AssetAmount)
+	return bc.NewTx(*data)
+}
+
+// Vote returns a signed transaction spending in, authorized by
+// from, and locking the same asset and amount to candidate until
+// deadline. The locked output's script remains from's, so from is
+// the one who can later unvote it with Unvote.
+func Vote(t *testing.T, in *Output, from *destFixture, candidate []byte, deadline uint64) *bc.Tx {
+	data := &bc.TxData{
+		Version: bc.CurrentTransactionVersion,
+		Inputs: []*bc.TxInput{
+			{
+				Previous: in.Outpoint,
+				Vote:     &bc.VoteInput{Candidate: candidate, Deadline: deadline},
+			},
+		},
+		Outputs: []*bc.TxOutput{
+			{
+				Script:      from.PKScript,
+				AssetAmount: in.AssetAmount,
+				Vote:        &bc.VoteOutput{Vote: candidate, Deadline: deadline},
+			},
+		},
+	}
+	from.Sign(t, data, 0, in.AssetAmount)
+	return bc.NewTx(*data)
+}
+
+// Unvote returns a signed transaction releasing a vote lock cast
+// by Vote: it spends in (a vote-locked output) and pays the same
+// asset and amount to to, exactly like Transfer. FC is responsible
+// for rejecting this before the lock's deadline; Unvote itself
+// builds an ordinary spend.
+func Unvote(t *testing.T, in *Output, from, to *destFixture) *bc.Tx {
+	return Transfer(t, in, from, to)
+}
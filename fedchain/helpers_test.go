@@ -0,0 +1,22 @@
+package fedchain
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"chain/fedchain/memstore"
+	"chain/testutil"
+)
+
+// newContextFC returns a background context and an FC backed by a
+// fresh MemStore, for tests that don't need to control store
+// construction themselves.
+func newContextFC(t *testing.T) (context.Context, *FC) {
+	ctx := context.Background()
+	fc, err := New(ctx, memstore.New(), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	return ctx, fc
+}
@@ -0,0 +1,80 @@
+// Package memstore provides an in-memory implementation of
+// fedchain.Store, suitable for tests and single-process prototypes.
+package memstore
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+
+	"chain/fedchain/bc"
+)
+
+// MemStore is a Store backed by plain Go maps. The zero value is
+// not usable; construct one with New.
+type MemStore struct {
+	mu      sync.Mutex
+	utxos   map[bc.Outpoint]*bc.TxOutput
+	spent   map[bc.Outpoint]bool
+	applied map[bc.Hash]bool
+}
+
+// New returns an empty MemStore.
+func New() *MemStore {
+	return &MemStore{
+		utxos:   make(map[bc.Outpoint]*bc.TxOutput),
+		spent:   make(map[bc.Outpoint]bool),
+		applied: make(map[bc.Hash]bool),
+	}
+}
+
+// ApplyTx records tx as confirmed: it consumes the outputs tx
+// spends and adds the outputs tx creates, including any that are
+// vote-locked. issued and voted are not used directly; they are
+// passed through so that the caller (or a wrapping Store, as in
+// tests) can observe the issuance and voting accounting for tx.
+// ApplyTx is idempotent: applying the same tx twice is a no-op the
+// second time.
+func (m *MemStore) ApplyTx(ctx context.Context, tx *bc.Tx, issued, voted map[bc.AssetID]uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.applied[tx.Hash] {
+		return nil
+	}
+	for _, in := range tx.Inputs {
+		if in.Previous.Index == bc.InvalidOutputIndex {
+			continue
+		}
+		delete(m.utxos, in.Previous)
+		m.spent[in.Previous] = true
+	}
+	for i, out := range tx.Outputs {
+		m.utxos[bc.Outpoint{Hash: tx.Hash, Index: uint32(i)}] = out
+	}
+	m.applied[tx.Hash] = true
+	return nil
+}
+
+// UTXO returns the output at op, if it is unspent.
+func (m *MemStore) UTXO(op bc.Outpoint) (*bc.TxOutput, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out, ok := m.utxos[op]
+	return out, ok
+}
+
+// IsSpent reports whether op has already been consumed by a
+// confirmed transaction.
+func (m *MemStore) IsSpent(op bc.Outpoint) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.spent[op]
+}
+
+// IsApplied reports whether tx has already been confirmed.
+func (m *MemStore) IsApplied(hash bc.Hash) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.applied[hash]
+}
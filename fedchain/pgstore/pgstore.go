@@ -0,0 +1,220 @@
+// Package pgstore implements fedchain.Store on top of PostgreSQL,
+// for nodes that want confirmed chain state to survive a restart
+// and be queryable with SQL, instead of living only in memstore's
+// in-process maps. The schema lives in schema.sql, alongside this
+// file.
+package pgstore
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"golang.org/x/net/context"
+
+	_ "github.com/lib/pq"
+
+	"chain/errors"
+	"chain/fedchain/bc"
+)
+
+// Store is a fedchain.Store backed by a Postgres database opened
+// with Open. Its zero value is not usable.
+type Store struct {
+	db *sql.DB
+}
+
+// Open connects to the Postgres database identified by
+// dataSourceName (a libpq connection string or URL) and returns a
+// Store over it. The schema in schema.sql must already be applied.
+func Open(dataSourceName string) (*Store, error) {
+	db, err := sql.Open("postgres", dataSourceName)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening postgres")
+	}
+	return &Store{db: db}, nil
+}
+
+// ApplyTx records tx as confirmed in a single database transaction,
+// under a new, single-transaction block row.
+func (s *Store) ApplyTx(ctx context.Context, tx *bc.Tx, issued, voted map[bc.AssetID]uint64) error {
+	dbtx, err := s.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	height, err := insertBlock(dbtx)
+	if err != nil {
+		dbtx.Rollback()
+		return err
+	}
+	if err := applyTx(dbtx, tx, height, issued); err != nil {
+		dbtx.Rollback()
+		return err
+	}
+	return dbtx.Commit()
+}
+
+// ApplyBlock applies every transaction in block within a single
+// database transaction: either all of them land, or none do. FC
+// prefers this over repeated ApplyTx calls when the Store provides
+// it; see fedchain.BlockApplier.
+func (s *Store) ApplyBlock(ctx context.Context, block *bc.Block, issued, voted []map[bc.AssetID]uint64) error {
+	dbtx, err := s.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	height, err := insertBlock(dbtx)
+	if err != nil {
+		dbtx.Rollback()
+		return err
+	}
+	for i, tx := range block.Transactions {
+		if err := applyTx(dbtx, tx, height, issued[i]); err != nil {
+			dbtx.Rollback()
+			return err
+		}
+	}
+	return dbtx.Commit()
+}
+
+// insertBlock records a new block row and returns its height, the
+// one assigned by the blocks table's serial primary key.
+func insertBlock(dbtx *sql.Tx) (int64, error) {
+	var height int64
+	err := dbtx.QueryRow(`INSERT INTO blocks DEFAULT VALUES RETURNING height`).Scan(&height)
+	if err != nil {
+		return 0, errors.Wrap(err, "inserting block")
+	}
+	return height, nil
+}
+
+func applyTx(dbtx *sql.Tx, tx *bc.Tx, blockHeight int64, issued map[bc.AssetID]uint64) error {
+	applied, err := isApplied(dbtx, tx.Hash)
+	if err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+
+	for _, in := range tx.Inputs {
+		if in.Previous.Index == bc.InvalidOutputIndex {
+			continue
+		}
+		_, err := dbtx.Exec(
+			`UPDATE utxos SET spent = true WHERE tx_hash = $1 AND index = $2`,
+			in.Previous.Hash[:], in.Previous.Index,
+		)
+		if err != nil {
+			return errors.Wrap(err, "marking spent ", in.Previous)
+		}
+	}
+
+	for i, out := range tx.Outputs {
+		data, err := json.Marshal(out)
+		if err != nil {
+			return errors.Wrap(err, "encoding output")
+		}
+		_, err = dbtx.Exec(
+			`INSERT INTO utxos (tx_hash, index, asset_id, amount, data, spent)
+			 VALUES ($1, $2, $3, $4, $5, false)`,
+			tx.Hash[:], i, out.AssetID[:], out.Amount, data,
+		)
+		if err != nil {
+			return errors.Wrap(err, "inserting output")
+		}
+	}
+
+	txData, err := json.Marshal(tx.TxData)
+	if err != nil {
+		return errors.Wrap(err, "encoding tx")
+	}
+	_, err = dbtx.Exec(
+		`INSERT INTO txs (tx_hash, block_height, data) VALUES ($1, $2, $3)`,
+		tx.Hash[:], blockHeight, txData,
+	)
+	if err != nil {
+		return errors.Wrap(err, "recording applied tx")
+	}
+
+	for assetID, amount := range issued {
+		if amount == 0 {
+			continue
+		}
+		_, err := dbtx.Exec(
+			`INSERT INTO issued_totals (asset_id, amount) VALUES ($1, $2)
+			 ON CONFLICT (asset_id) DO UPDATE SET amount = issued_totals.amount + EXCLUDED.amount`,
+			assetID[:], amount,
+		)
+		if err != nil {
+			return errors.Wrap(err, "updating issued total for ", assetID)
+		}
+	}
+
+	return nil
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx, so isApplied
+// can run inside or outside an existing transaction.
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func isApplied(q queryer, hash bc.Hash) (bool, error) {
+	var n int
+	err := q.QueryRow(`SELECT count(*) FROM txs WHERE tx_hash = $1`, hash[:]).Scan(&n)
+	if err != nil {
+		return false, errors.Wrap(err, "checking applied")
+	}
+	return n > 0, nil
+}
+
+// UTXO returns the confirmed, unspent output at op, if any.
+func (s *Store) UTXO(op bc.Outpoint) (*bc.TxOutput, bool) {
+	var data []byte
+	err := s.db.QueryRow(
+		`SELECT data FROM utxos WHERE tx_hash = $1 AND index = $2 AND NOT spent`,
+		op.Hash[:], op.Index,
+	).Scan(&data)
+	if err != nil {
+		return nil, false
+	}
+	var out bc.TxOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, false
+	}
+	return &out, true
+}
+
+// IsSpent reports whether op has already been consumed by a
+// confirmed transaction.
+func (s *Store) IsSpent(op bc.Outpoint) bool {
+	var spent bool
+	err := s.db.QueryRow(
+		`SELECT spent FROM utxos WHERE tx_hash = $1 AND index = $2`,
+		op.Hash[:], op.Index,
+	).Scan(&spent)
+	if err != nil {
+		return false
+	}
+	return spent
+}
+
+// IsApplied reports whether tx has already been confirmed.
+func (s *Store) IsApplied(hash bc.Hash) bool {
+	applied, err := isApplied(s.db, hash)
+	return err == nil && applied
+}
+
+// IssuedTotal returns the cumulative amount of assetID issued
+// across every confirmed transaction.
+func (s *Store) IssuedTotal(assetID bc.AssetID) uint64 {
+	var amount uint64
+	err := s.db.QueryRow(
+		`SELECT amount FROM issued_totals WHERE asset_id = $1`,
+		assetID[:],
+	).Scan(&amount)
+	if err != nil {
+		return 0
+	}
+	return amount
+}
@@ -0,0 +1,43 @@
+package pgstore
+
+import (
+	"os"
+	"testing"
+
+	"chain/fedchain"
+	"chain/fedchain/storetest"
+)
+
+// TestStore runs the fedchain.Store acceptance suite against a live
+// Postgres database. Set PGSTORE_TEST_DSN to a connection string
+// for an empty database with schema.sql already applied to run it;
+// it is skipped otherwise, since CI doesn't provide Postgres by
+// default.
+func TestStore(t *testing.T) {
+	dsn := os.Getenv("PGSTORE_TEST_DSN")
+	if dsn == "" {
+		t.Skip("PGSTORE_TEST_DSN not set")
+	}
+	storetest.Run(t, func() fedchain.Store {
+		store, err := Open(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reset(t, store)
+		return store
+	})
+}
+
+func reset(t *testing.T, s *Store) {
+	if _, err := s.db.Exec(`DELETE FROM utxos`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.Exec(`DELETE FROM issued_totals`); err != nil {
+		t.Fatal(err)
+	}
+	// txs references blocks with ON DELETE CASCADE, so clearing
+	// blocks is enough to empty both tables.
+	if _, err := s.db.Exec(`DELETE FROM blocks`); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,203 @@
+package pgstore
+
+import (
+	"encoding/json"
+
+	"golang.org/x/net/context"
+
+	"chain/errors"
+	"chain/fedchain/bc"
+)
+
+// snapshot is the JSON form a Snapshot produces and Restore
+// consumes: the entire confirmed UTXO set, the blocks and txs that
+// produced it, and the cumulative issuance totals they carried.
+type snapshot struct {
+	UTXOs  []snapshotUTXO
+	Blocks []int64
+	Txs    []snapshotTx
+	Issued []snapshotIssued
+}
+
+type snapshotUTXO struct {
+	TxHash bc.Hash
+	Index  uint32
+	Data   json.RawMessage
+	Spent  bool
+}
+
+type snapshotTx struct {
+	TxHash      bc.Hash
+	BlockHeight int64
+	Data        json.RawMessage
+}
+
+type snapshotIssued struct {
+	AssetID bc.AssetID
+	Amount  uint64
+}
+
+// Snapshot encodes the entire confirmed UTXO set, the blocks and
+// txs that produced it, and the cumulative issuance totals, as
+// JSON, so a new node can bootstrap its Store from a single blob
+// instead of replaying every block from genesis.
+func (s *Store) Snapshot(ctx context.Context) ([]byte, error) {
+	var snap snapshot
+
+	rows, err := s.db.Query(`SELECT tx_hash, index, data, spent FROM utxos`)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying utxos")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var u snapshotUTXO
+		var hash []byte
+		if err := rows.Scan(&hash, &u.Index, &u.Data, &u.Spent); err != nil {
+			return nil, errors.Wrap(err, "scanning utxo row")
+		}
+		copy(u.TxHash[:], hash)
+		snap.UTXOs = append(snap.UTXOs, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterating utxos")
+	}
+
+	blockRows, err := s.db.Query(`SELECT height FROM blocks`)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying blocks")
+	}
+	defer blockRows.Close()
+	for blockRows.Next() {
+		var height int64
+		if err := blockRows.Scan(&height); err != nil {
+			return nil, errors.Wrap(err, "scanning block row")
+		}
+		snap.Blocks = append(snap.Blocks, height)
+	}
+	if err := blockRows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterating blocks")
+	}
+
+	txRows, err := s.db.Query(`SELECT tx_hash, block_height, data FROM txs`)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying txs")
+	}
+	defer txRows.Close()
+	for txRows.Next() {
+		var tx snapshotTx
+		var hash []byte
+		if err := txRows.Scan(&hash, &tx.BlockHeight, &tx.Data); err != nil {
+			return nil, errors.Wrap(err, "scanning tx row")
+		}
+		copy(tx.TxHash[:], hash)
+		snap.Txs = append(snap.Txs, tx)
+	}
+	if err := txRows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterating txs")
+	}
+
+	issuedRows, err := s.db.Query(`SELECT asset_id, amount FROM issued_totals`)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying issued_totals")
+	}
+	defer issuedRows.Close()
+	for issuedRows.Next() {
+		var iss snapshotIssued
+		var assetID []byte
+		if err := issuedRows.Scan(&assetID, &iss.Amount); err != nil {
+			return nil, errors.Wrap(err, "scanning issued_totals row")
+		}
+		copy(iss.AssetID[:], assetID)
+		snap.Issued = append(snap.Issued, iss)
+	}
+	if err := issuedRows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterating issued_totals")
+	}
+
+	return json.Marshal(snap)
+}
+
+// Restore replaces the Store's entire confirmed state with the
+// contents of data, a blob produced by Snapshot, in a single
+// transaction. It is meant for an empty, freshly-migrated database,
+// bootstrapping a new node without replaying history from genesis.
+func (s *Store) Restore(ctx context.Context, data []byte) error {
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return errors.Wrap(err, "decoding snapshot")
+	}
+
+	dbtx, err := s.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+
+	if _, err := dbtx.Exec(`DELETE FROM utxos`); err != nil {
+		dbtx.Rollback()
+		return errors.Wrap(err, "clearing utxos")
+	}
+	if _, err := dbtx.Exec(`DELETE FROM issued_totals`); err != nil {
+		dbtx.Rollback()
+		return errors.Wrap(err, "clearing issued_totals")
+	}
+	// txs references blocks with ON DELETE CASCADE, so clearing
+	// blocks is enough to empty both tables.
+	if _, err := dbtx.Exec(`DELETE FROM blocks`); err != nil {
+		dbtx.Rollback()
+		return errors.Wrap(err, "clearing blocks")
+	}
+
+	for _, u := range snap.UTXOs {
+		var out bc.TxOutput
+		if err := json.Unmarshal(u.Data, &out); err != nil {
+			dbtx.Rollback()
+			return errors.Wrap(err, "decoding snapshot utxo")
+		}
+		_, err := dbtx.Exec(
+			`INSERT INTO utxos (tx_hash, index, asset_id, amount, data, spent)
+			 VALUES ($1, $2, $3, $4, $5, $6)`,
+			u.TxHash[:], u.Index, out.AssetID[:], out.Amount, []byte(u.Data), u.Spent,
+		)
+		if err != nil {
+			dbtx.Rollback()
+			return errors.Wrap(err, "restoring utxo")
+		}
+	}
+	for _, height := range snap.Blocks {
+		if _, err := dbtx.Exec(`INSERT INTO blocks (height) VALUES ($1)`, height); err != nil {
+			dbtx.Rollback()
+			return errors.Wrap(err, "restoring block")
+		}
+	}
+	// Explicit-height inserts above bypass the blocks.height serial,
+	// so the sequence needs to be caught up before insertBlock can
+	// hand out heights again without colliding with what we just
+	// restored.
+	_, err = dbtx.Exec(`SELECT setval(pg_get_serial_sequence('blocks', 'height'), COALESCE((SELECT max(height) FROM blocks), 1), (SELECT max(height) FROM blocks) IS NOT NULL)`)
+	if err != nil {
+		dbtx.Rollback()
+		return errors.Wrap(err, "resyncing blocks height sequence")
+	}
+	for _, tx := range snap.Txs {
+		_, err := dbtx.Exec(
+			`INSERT INTO txs (tx_hash, block_height, data) VALUES ($1, $2, $3)`,
+			tx.TxHash[:], tx.BlockHeight, []byte(tx.Data),
+		)
+		if err != nil {
+			dbtx.Rollback()
+			return errors.Wrap(err, "restoring tx")
+		}
+	}
+	for _, iss := range snap.Issued {
+		_, err := dbtx.Exec(
+			`INSERT INTO issued_totals (asset_id, amount) VALUES ($1, $2)`,
+			iss.AssetID[:], iss.Amount,
+		)
+		if err != nil {
+			dbtx.Rollback()
+			return errors.Wrap(err, "restoring issued total")
+		}
+	}
+
+	return dbtx.Commit()
+}
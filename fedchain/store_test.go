@@ -0,0 +1,18 @@
+package fedchain_test
+
+import (
+	"testing"
+
+	"chain/fedchain"
+	"chain/fedchain/memstore"
+	"chain/fedchain/storetest"
+)
+
+// TestMemStore confirms memstore.MemStore satisfies the Store
+// acceptance suite — the same suite every other backend (pgstore,
+// boltstore, ...) must pass too.
+func TestMemStore(t *testing.T) {
+	storetest.Run(t, func() fedchain.Store {
+		return memstore.New()
+	})
+}
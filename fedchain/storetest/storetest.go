@@ -0,0 +1,276 @@
+// Package storetest provides an acceptance suite that any
+// fedchain.Store implementation must pass. New backends (pgstore,
+// boltstore, ...) should call Run against a fresh instance to
+// confirm they behave like memstore, the reference implementation
+// FC was designed against.
+package storetest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"chain/errors"
+	"chain/fedchain"
+	"chain/fedchain/bc"
+	"chain/fedchain/fedtest"
+	"chain/testutil"
+)
+
+// Run runs the acceptance suite as subtests of t, calling newStore
+// to construct a fresh, empty store for each one.
+func Run(t *testing.T, newStore func() fedchain.Store) {
+	t.Run("AddTx", func(t *testing.T) { testAddTx(t, newStore) })
+	t.Run("IdempotentAddTx", func(t *testing.T) { testIdempotentAddTx(t, newStore) })
+	t.Run("AddTxIssued", func(t *testing.T) { testAddTxIssued(t, newStore) })
+	t.Run("SnapshotRestore", func(t *testing.T) { testSnapshotRestore(t, newStore) })
+}
+
+// snapshotStore is the optional fedchain.Store extension pgstore and
+// boltstore provide for fast node bootstrap. Backends that don't
+// support it (memstore) are skipped by testSnapshotRestore rather
+// than failed.
+type snapshotStore interface {
+	fedchain.Store
+	Snapshot(ctx context.Context) ([]byte, error)
+	Restore(ctx context.Context, data []byte) error
+}
+
+// issuedTotaler is the further-optional extension a store may
+// provide to report its cumulative issuance accounting; pgstore
+// does, boltstore and memstore don't. testSnapshotRestore checks it
+// when available, but doesn't require it.
+type issuedTotaler interface {
+	IssuedTotal(assetID bc.AssetID) uint64
+}
+
+func testSnapshotRestore(t *testing.T, newStore func() fedchain.Store) {
+	ctx := context.Background()
+
+	store := newStore()
+	snap, ok := store.(snapshotStore)
+	if !ok {
+		t.Skip("store does not support Snapshot/Restore")
+	}
+
+	fc, err := fedchain.New(ctx, store, nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	asset0 := fedtest.Asset(t)
+	dest0 := fedtest.Dest(t)
+	dest1 := fedtest.Dest(t)
+
+	issueTx, _, _ := fedtest.Issue(t, asset0, dest0, 10)
+	confirmTx(t, ctx, fc, issueTx)
+
+	transferTx := fedtest.Transfer(t, fedtest.StateOut(issueTx, 0), dest0, dest1)
+	confirmTx(t, ctx, fc, transferTx)
+
+	issuedOutpoint := bc.Outpoint{Hash: issueTx.Hash, Index: 0}
+	transferOutpoint := bc.Outpoint{Hash: transferTx.Hash, Index: 0}
+
+	data, err := snap.Snapshot(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	restored := newStore().(snapshotStore)
+	if err := restored.Restore(ctx, data); err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	if !restored.IsSpent(issuedOutpoint) {
+		t.Error("restored store: issued output not spent, want spent")
+	}
+	out, ok := restored.UTXO(transferOutpoint)
+	if !ok {
+		t.Fatal("restored store: transfer output missing, want present")
+	}
+	if out.AssetID != asset0.AssetID || out.Amount != 10 {
+		t.Errorf("restored store: transfer output = %+v, want asset %x amount 10", out, asset0.AssetID)
+	}
+
+	if totaler, ok := store.(issuedTotaler); ok {
+		restoredTotaler := restored.(issuedTotaler)
+		want := totaler.IssuedTotal(asset0.AssetID)
+		got := restoredTotaler.IssuedTotal(asset0.AssetID)
+		if got != want {
+			t.Errorf("restored store: IssuedTotal = %d, want %d", got, want)
+		}
+	}
+}
+
+// confirmTx adds tx to fc, assembles a block containing just it,
+// and lands that block, so that tx's UTXOs are available from the
+// store rather than only from the pool.
+func confirmTx(t *testing.T, ctx context.Context, fc *fedchain.FC, tx *bc.Tx) {
+	if err := fc.AddTx(ctx, tx); err != nil {
+		testutil.FatalErr(t, err)
+	}
+	block, _, err := fc.GenerateBlock(ctx, time.Now())
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if err := fc.AddBlock(ctx, block); err != nil {
+		testutil.FatalErr(t, err)
+	}
+}
+
+func testAddTx(t *testing.T, newStore func() fedchain.Store) {
+	ctx := context.Background()
+	fc, err := fedchain.New(ctx, newStore(), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	issueTx, _, dest1 := fedtest.Issue(t, nil, nil, 1)
+	err = fc.AddTx(ctx, issueTx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	transferTx := fedtest.Transfer(t, fedtest.StateOut(issueTx, 0), dest1, fedtest.Dest(t))
+	err = fc.AddTx(ctx, transferTx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	invalidTransfer := fedtest.Transfer(t, fedtest.StateOut(issueTx, 0), dest1, fedtest.Dest(t))
+	err = fc.AddTx(ctx, invalidTransfer)
+	if errors.Root(err) != fedchain.ErrTxRejected {
+		t.Fatalf("got err = %q want %q", errors.Root(err), fedchain.ErrTxRejected)
+	}
+}
+
+func testIdempotentAddTx(t *testing.T, newStore func() fedchain.Store) {
+	ctx := context.Background()
+	fc, err := fedchain.New(ctx, newStore(), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	issueTx, _, _ := fedtest.Issue(t, nil, nil, 1)
+
+	for i := 0; i < 2; i++ {
+		if err := fc.AddTx(ctx, issueTx); err != nil {
+			testutil.FatalErr(t, err)
+		}
+	}
+
+	block, _, err := fc.GenerateBlock(ctx, time.Now())
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if err := fc.AddBlock(ctx, block); err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	// still idempotent after the block lands
+	if err := fc.AddTx(ctx, issueTx); err != nil {
+		testutil.FatalErr(t, err)
+	}
+}
+
+// observingStore wraps a Store and remembers the issued map passed
+// to the most recent ApplyTx call, so testAddTxIssued can assert on
+// it regardless of what the underlying Store does with it.
+type observingStore struct {
+	fedchain.Store
+	lastIssued map[bc.AssetID]uint64
+}
+
+func (s *observingStore) ApplyTx(ctx context.Context, tx *bc.Tx, issued, voted map[bc.AssetID]uint64) error {
+	err := s.Store.ApplyTx(ctx, tx, issued, voted)
+	s.lastIssued = issued
+	return err
+}
+
+func (s *observingStore) IsApplied(hash bc.Hash) bool {
+	if a, ok := s.Store.(interface {
+		IsApplied(bc.Hash) bool
+	}); ok {
+		return a.IsApplied(hash)
+	}
+	return false
+}
+
+func testAddTxIssued(t *testing.T, newStore func() fedchain.Store) {
+	ctx := context.Background()
+
+	asset0 := fedtest.Asset(t)
+	asset1 := fedtest.Asset(t)
+	dest0 := fedtest.Dest(t)
+	dest1 := fedtest.Dest(t)
+
+	basicIssue, _, _ := fedtest.Issue(t, asset0, dest0, 10)
+	basicTransfer := fedtest.Transfer(t, fedtest.StateOut(basicIssue, 0), dest0, dest1)
+
+	multiIssueData := &bc.TxData{
+		Version: bc.CurrentTransactionVersion,
+		Inputs: []*bc.TxInput{
+			{Previous: bc.Outpoint{Index: bc.InvalidOutputIndex}},
+			{Previous: bc.Outpoint{Index: bc.InvalidOutputIndex}},
+		},
+		Outputs: []*bc.TxOutput{
+			{Script: dest0.PKScript, AssetAmount: bc.AssetAmount{AssetID: asset0.AssetID, Amount: 2}},
+			{Script: dest0.PKScript, AssetAmount: bc.AssetAmount{AssetID: asset1.AssetID, Amount: 3}},
+		},
+	}
+	asset0.Sign(t, multiIssueData, 0, bc.AssetAmount{})
+	asset1.Sign(t, multiIssueData, 1, bc.AssetAmount{})
+	multiIssue := bc.NewTx(*multiIssueData)
+
+	issueTransferData := &bc.TxData{
+		Version: bc.CurrentTransactionVersion,
+		Inputs: []*bc.TxInput{
+			{Previous: bc.Outpoint{Hash: multiIssue.Hash, Index: 1}},
+			{Previous: bc.Outpoint{Index: bc.InvalidOutputIndex}},
+		},
+		Outputs: []*bc.TxOutput{
+			{Script: dest0.PKScript, AssetAmount: bc.AssetAmount{AssetID: asset0.AssetID, Amount: 4}},
+			{Script: dest1.PKScript, AssetAmount: bc.AssetAmount{AssetID: asset1.AssetID, Amount: 3}},
+		},
+	}
+	dest0.Sign(t, issueTransferData, 0, multiIssue.Outputs[1].AssetAmount)
+	asset0.Sign(t, issueTransferData, 1, bc.AssetAmount{})
+	issueTransfer := bc.NewTx(*issueTransferData)
+
+	store := &observingStore{Store: newStore()}
+	fc, err := fedchain.New(ctx, store, nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	cases := []struct {
+		tx   *bc.Tx
+		want map[bc.AssetID]uint64
+	}{
+		{tx: basicIssue, want: map[bc.AssetID]uint64{asset0.AssetID: 10}},
+		{tx: basicTransfer, want: map[bc.AssetID]uint64{}},
+		{tx: multiIssue, want: map[bc.AssetID]uint64{asset0.AssetID: 2, asset1.AssetID: 3}},
+		{tx: issueTransfer, want: map[bc.AssetID]uint64{asset0.AssetID: 4, asset1.AssetID: 0}},
+	}
+	for _, c := range cases {
+		if err := fc.AddTx(ctx, c.tx); err != nil {
+			testutil.FatalErr(t, err)
+		}
+
+		// Issuance accounting is only reported to the store once a
+		// tx is confirmed, so assemble and land a block containing
+		// just this tx before checking it.
+		block, _, err := fc.GenerateBlock(ctx, time.Now())
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		if err := fc.AddBlock(ctx, block); err != nil {
+			testutil.FatalErr(t, err)
+		}
+		if !reflect.DeepEqual(store.lastIssued, c.want) {
+			t.Errorf("got issued = %+v want %+v", store.lastIssued, c.want)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+// Package txpool holds transactions that have been admitted as
+// individually valid but not yet confirmed in a block. It is the
+// single place fedchain.FC consults when deciding what to put in
+// the next block, and the single place it reconciles against once
+// a block lands.
+package txpool
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"chain/errors"
+	"chain/fedchain/bc"
+)
+
+// Default eviction policy parameters, used when a Pool is
+// constructed with a zero Options.
+const (
+	DefaultTTL     = 2 * time.Hour
+	DefaultMaxSize = 50000
+)
+
+// ErrConflict is the root of errors returned by Add when tx
+// conflicts with a transaction already in the pool.
+var ErrConflict = errors.New("conflicts with a pending transaction")
+
+// EventKind identifies the kind of Event emitted by a Pool.
+type EventKind int
+
+const (
+	// EventAdmit fires when a transaction is added to the pool.
+	EventAdmit EventKind = iota
+	// EventEvict fires when a transaction leaves the pool without
+	// being confirmed (TTL expiry, size pressure, or a conflict
+	// discovered at confirmation time).
+	EventEvict
+)
+
+// Event describes a state change in the pool.
+type Event struct {
+	Kind EventKind
+	Hash bc.Hash
+}
+
+// Tx is a pending transaction together with the bookkeeping the
+// pool needs to order and evict it.
+type Tx struct {
+	Tx      *bc.Tx
+	Issued  map[bc.AssetID]uint64
+	Voted   map[bc.AssetID]uint64
+	Arrived time.Time
+	Fee     uint64
+	Size    int
+	Spends  []bc.Outpoint // outpoints this tx consumes
+}
+
+// Options configures eviction policy for a Pool.
+type Options struct {
+	// TTL is how long a pending transaction may sit in the pool
+	// before it is evicted. Zero means DefaultTTL.
+	TTL time.Duration
+	// MaxSize is the maximum number of pending transactions the
+	// pool will hold before evicting the oldest entries (and the
+	// pending entries that spend from them) to make room. Fee plays
+	// no part in the choice. Zero means DefaultMaxSize.
+	MaxSize int
+}
+
+// Stats summarizes the current state of a Pool.
+type Stats struct {
+	Count   int
+	Bytes   int
+	Evicted int
+}
+
+// Pool holds pending transactions. The zero value is not usable;
+// construct one with New.
+type Pool struct {
+	opts Options
+
+	mu      sync.Mutex
+	byHash  map[bc.Hash]*Tx
+	byOut   map[bc.Outpoint]bc.Hash // outpoint -> pending spender
+	order   []bc.Hash               // arrival order, oldest first
+	evicted int
+
+	events chan Event
+}
+
+// New returns an empty Pool. Each zero field of opts takes the
+// package default.
+func New(opts Options) *Pool {
+	if opts.TTL == 0 {
+		opts.TTL = DefaultTTL
+	}
+	if opts.MaxSize == 0 {
+		opts.MaxSize = DefaultMaxSize
+	}
+	return &Pool{
+		opts:   opts,
+		byHash: make(map[bc.Hash]*Tx),
+		byOut:  make(map[bc.Outpoint]bc.Hash),
+		events: make(chan Event, 256),
+	}
+}
+
+// Events returns the channel on which admit/evict events are
+// delivered. Consumers that don't care about events may ignore it;
+// the channel is buffered and Add/Remove never block on it.
+func (p *Pool) Events() <-chan Event { return p.events }
+
+func (p *Pool) emit(ev Event) {
+	select {
+	case p.events <- ev:
+	default:
+		// Slow consumer; drop rather than block the pool.
+	}
+}
+
+// Add admits tx into the pool with the given issuance and voting
+// accounting (as computed by the caller against the confirmed UTXO
+// set). It returns ErrConflict if tx spends an outpoint already
+// spent by another pending transaction. Adding a transaction
+// already in the pool is a no-op.
+func (p *Pool) Add(ctx context.Context, tx *bc.Tx, issued, voted map[bc.AssetID]uint64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.byHash[tx.Hash]; ok {
+		return nil
+	}
+
+	entry := &Tx{
+		Tx:      tx,
+		Issued:  issued,
+		Voted:   voted,
+		Arrived: time.Now(),
+		Size:    txSize(tx),
+	}
+	for _, in := range tx.Inputs {
+		if in.Previous.Index == bc.InvalidOutputIndex {
+			continue
+		}
+		if spender, ok := p.byOut[in.Previous]; ok && spender != tx.Hash {
+			return errors.Wrap(ErrConflict, "outpoint ", in.Previous, " already spent by pending tx")
+		}
+		entry.Spends = append(entry.Spends, in.Previous)
+	}
+
+	p.byHash[tx.Hash] = entry
+	for _, op := range entry.Spends {
+		p.byOut[op] = tx.Hash
+	}
+	p.order = append(p.order, tx.Hash)
+	p.emit(Event{Kind: EventAdmit, Hash: tx.Hash})
+
+	p.evictLocked()
+	return nil
+}
+
+// evictLocked drops expired entries and, if the pool is over
+// MaxSize, the oldest remaining entries, until it fits. Evicting an
+// entry also evicts every pending entry that spends from it
+// (directly or transitively), so a surviving entry never depends on
+// one that left the pool uncomfirmed. Callers must hold p.mu.
+func (p *Pool) evictLocked() {
+	cutoff := time.Now().Add(-p.opts.TTL)
+	removed := map[bc.Hash]bool{}
+	for _, h := range p.order {
+		if removed[h] {
+			continue
+		}
+		entry, ok := p.byHash[h]
+		if !ok || !entry.Arrived.Before(cutoff) {
+			continue
+		}
+		for _, r := range p.evictWithDependents(h) {
+			removed[r] = true
+			p.evicted++
+			p.emit(Event{Kind: EventEvict, Hash: r})
+		}
+	}
+	kept := p.order[:0]
+	for _, h := range p.order {
+		if !removed[h] {
+			kept = append(kept, h)
+		}
+	}
+	p.order = kept
+
+	for len(p.order) > p.opts.MaxSize {
+		h := p.order[0]
+		for _, r := range p.evictWithDependents(h) {
+			p.evicted++
+			p.emit(Event{Kind: EventEvict, Hash: r})
+		}
+		kept := p.order[:0]
+		for _, hh := range p.order {
+			if _, ok := p.byHash[hh]; ok {
+				kept = append(kept, hh)
+			}
+		}
+		p.order = kept
+	}
+}
+
+// evictWithDependents removes h from the pool and, transitively,
+// every pending entry that spends an output of an entry being
+// removed. It returns every hash removed this way, in no particular
+// order. Callers must hold p.mu and are responsible for removing the
+// returned hashes from p.order themselves.
+func (p *Pool) evictWithDependents(h bc.Hash) []bc.Hash {
+	var evicted []bc.Hash
+	removed := map[bc.Hash]bool{}
+	queue := []bc.Hash{h}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if removed[cur] {
+			continue
+		}
+		if _, ok := p.byHash[cur]; !ok {
+			continue
+		}
+		removed[cur] = true
+		evicted = append(evicted, cur)
+		for _, other := range p.order {
+			if removed[other] {
+				continue
+			}
+			entry, ok := p.byHash[other]
+			if !ok {
+				continue
+			}
+			for _, op := range entry.Spends {
+				if op.Hash == cur {
+					queue = append(queue, other)
+					break
+				}
+			}
+		}
+		p.removeLocked(cur)
+	}
+	return evicted
+}
+
+// removeLocked deletes h from byHash and byOut. Callers must hold
+// p.mu and are responsible for removing h from p.order themselves.
+func (p *Pool) removeLocked(h bc.Hash) {
+	entry, ok := p.byHash[h]
+	if !ok {
+		return
+	}
+	for _, op := range entry.Spends {
+		if p.byOut[op] == h {
+			delete(p.byOut, op)
+		}
+	}
+	delete(p.byHash, h)
+}
+
+// Remove evicts tx from the pool, if present.
+func (p *Pool) Remove(hash bc.Hash) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.byHash[hash]; !ok {
+		return
+	}
+	p.removeLocked(hash)
+	for i, h := range p.order {
+		if h == hash {
+			p.order = append(p.order[:i], p.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Get returns the pending entry for hash, or nil if it is not in
+// the pool.
+func (p *Pool) Get(hash bc.Hash) *Tx {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.byHash[hash]
+}
+
+// Dump returns all pending transactions, ordered so that a tx
+// never precedes one it depends on.
+func (p *Pool) Dump() []*Tx {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]*Tx, 0, len(p.order))
+	placed := make(map[bc.Hash]bool, len(p.order))
+
+	var place func(h bc.Hash)
+	place = func(h bc.Hash) {
+		if placed[h] {
+			return
+		}
+		entry, ok := p.byHash[h]
+		if !ok {
+			return
+		}
+		placed[h] = true
+		for _, op := range entry.Spends {
+			if _, ok := p.byHash[op.Hash]; ok {
+				place(op.Hash)
+			}
+		}
+		out = append(out, entry)
+	}
+	for _, h := range p.order {
+		place(h)
+	}
+	return out
+}
+
+// RemoveConfirmed drops from the pool every transaction in block,
+// and evicts any remaining pending transaction that now conflicts
+// with an outpoint the block consumed (a double-spend that lost
+// the race).
+func (p *Pool) RemoveConfirmed(block *bc.Block) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	consumed := make(map[bc.Outpoint]bool)
+	confirmed := make(map[bc.Hash]bool)
+	for _, tx := range block.Transactions {
+		confirmed[tx.Hash] = true
+		for _, in := range tx.Inputs {
+			if in.Previous.Index != bc.InvalidOutputIndex {
+				consumed[in.Previous] = true
+			}
+		}
+		p.removeLocked(tx.Hash)
+	}
+
+	var kept []bc.Hash
+	for _, h := range p.order {
+		if confirmed[h] {
+			continue
+		}
+		entry, ok := p.byHash[h]
+		if !ok {
+			continue
+		}
+		conflict := false
+		for _, op := range entry.Spends {
+			if consumed[op] {
+				conflict = true
+				break
+			}
+		}
+		if conflict {
+			p.removeLocked(h)
+			p.evicted++
+			p.emit(Event{Kind: EventEvict, Hash: h})
+			continue
+		}
+		kept = append(kept, h)
+	}
+	p.order = kept
+}
+
+// Stats returns a snapshot of the pool's current size and
+// cumulative eviction count.
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	bytes := 0
+	for _, h := range p.order {
+		bytes += p.byHash[h].Size
+	}
+	return Stats{Count: len(p.order), Bytes: bytes, Evicted: p.evicted}
+}
+
+// txSize approximates the wire size of tx, for pool accounting
+// purposes; it need not match a real serializer byte-for-byte.
+func txSize(tx *bc.Tx) int {
+	size := 32 // tx hash
+	for _, in := range tx.Inputs {
+		size += 32 + 4 + len(in.SignatureScript)
+	}
+	for _, out := range tx.Outputs {
+		size += 32 + 8 + len(out.Script)
+	}
+	return size
+}
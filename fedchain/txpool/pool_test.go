@@ -0,0 +1,204 @@
+package txpool
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"chain/errors"
+	"chain/fedchain/bc"
+	"chain/fedchain/fedtest"
+	"chain/testutil"
+)
+
+func TestAddAndGet(t *testing.T) {
+	ctx := context.Background()
+	pool := New(Options{})
+
+	issueTx, _, dest := fedtest.Issue(t, nil, nil, 1)
+	err := pool.Add(ctx, issueTx, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	if pool.Get(issueTx.Hash) == nil {
+		t.Fatal("Get after Add = nil, want entry")
+	}
+
+	// Adding the same tx again is a no-op, not a conflict.
+	err = pool.Add(ctx, issueTx, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{})
+	if err != nil {
+		t.Fatalf("re-Add: got err = %v, want nil", err)
+	}
+	_ = dest
+}
+
+func TestAddConflict(t *testing.T) {
+	ctx := context.Background()
+	pool := New(Options{})
+
+	issueTx, _, dest0 := fedtest.Issue(t, nil, nil, 1)
+	dest1 := fedtest.Dest(t)
+	dest2 := fedtest.Dest(t)
+
+	out := fedtest.StateOut(issueTx, 0)
+	transfer1 := fedtest.Transfer(t, out, dest0, dest1)
+	transfer2 := fedtest.Transfer(t, out, dest0, dest2)
+
+	if err := pool.Add(ctx, issueTx, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{}); err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if err := pool.Add(ctx, transfer1, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{}); err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err := pool.Add(ctx, transfer2, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{})
+	if errors.Root(err) != ErrConflict {
+		t.Fatalf("got err = %v, want ErrConflict", err)
+	}
+}
+
+func TestDumpDependencyOrder(t *testing.T) {
+	ctx := context.Background()
+	pool := New(Options{})
+
+	issueTx, _, dest0 := fedtest.Issue(t, nil, nil, 1)
+	dest1 := fedtest.Dest(t)
+	transfer := fedtest.Transfer(t, fedtest.StateOut(issueTx, 0), dest0, dest1)
+
+	// Admit the dependent tx first; Dump must still place issueTx
+	// ahead of it.
+	if err := pool.Add(ctx, transfer, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{}); err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if err := pool.Add(ctx, issueTx, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{}); err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	dump := pool.Dump()
+	if len(dump) != 2 {
+		t.Fatalf("got %d entries, want 2", len(dump))
+	}
+	if dump[0].Tx.Hash != issueTx.Hash {
+		t.Fatalf("got dump[0] = %x, want issueTx %x", dump[0].Tx.Hash, issueTx.Hash)
+	}
+	if dump[1].Tx.Hash != transfer.Hash {
+		t.Fatalf("got dump[1] = %x, want transfer %x", dump[1].Tx.Hash, transfer.Hash)
+	}
+}
+
+func TestRemoveConfirmedEvictsConflicts(t *testing.T) {
+	ctx := context.Background()
+	pool := New(Options{})
+
+	issueTx, _, dest0 := fedtest.Issue(t, nil, nil, 1)
+	dest1 := fedtest.Dest(t)
+	dest2 := fedtest.Dest(t)
+	out := fedtest.StateOut(issueTx, 0)
+	losing := fedtest.Transfer(t, out, dest0, dest2)
+	winning := fedtest.Transfer(t, out, dest0, dest1)
+
+	if err := pool.Add(ctx, issueTx, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{}); err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if err := pool.Add(ctx, winning, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{}); err != nil {
+		testutil.FatalErr(t, err)
+	}
+	// losing conflicts with winning and is rejected at Add time...
+	if err := pool.Add(ctx, losing, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{}); errors.Root(err) != ErrConflict {
+		t.Fatalf("got err = %v, want ErrConflict", err)
+	}
+	// ...but suppose it had come from elsewhere and landed in a
+	// block anyway: RemoveConfirmed must still evict our local
+	// winning tx, since the block's version of the spend won.
+	block := &bc.Block{Transactions: []*bc.Tx{issueTx, losing}}
+	pool.RemoveConfirmed(block)
+
+	if pool.Get(winning.Hash) != nil {
+		t.Fatal("winning still pending after a conflicting confirmation, want evicted")
+	}
+	if pool.Get(issueTx.Hash) != nil {
+		t.Fatal("issueTx still pending after confirmation, want removed")
+	}
+}
+
+func TestEvictionTTL(t *testing.T) {
+	ctx := context.Background()
+	pool := New(Options{TTL: time.Millisecond})
+
+	issueTx, _, _ := fedtest.Issue(t, nil, nil, 1)
+	if err := pool.Add(ctx, issueTx, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{}); err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	// Triggering any Add runs the eviction sweep.
+	other, _, _ := fedtest.Issue(t, nil, nil, 1)
+	if err := pool.Add(ctx, other, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{}); err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	if pool.Get(issueTx.Hash) != nil {
+		t.Fatal("expired tx still pending, want evicted")
+	}
+	if got := pool.Stats().Evicted; got != 1 {
+		t.Fatalf("got Evicted = %d, want 1", got)
+	}
+}
+
+func TestEvictionMaxSize(t *testing.T) {
+	ctx := context.Background()
+	pool := New(Options{MaxSize: 1})
+
+	first, _, _ := fedtest.Issue(t, nil, nil, 1)
+	second, _, _ := fedtest.Issue(t, nil, nil, 1)
+
+	if err := pool.Add(ctx, first, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{}); err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if err := pool.Add(ctx, second, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{}); err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	if pool.Get(first.Hash) != nil {
+		t.Fatal("oldest tx still pending over MaxSize, want evicted")
+	}
+	if pool.Get(second.Hash) == nil {
+		t.Fatal("newest tx missing, want pending")
+	}
+}
+
+func TestEvictionMaxSizeEvictsDependents(t *testing.T) {
+	ctx := context.Background()
+	pool := New(Options{MaxSize: 2})
+
+	issueTx, _, dest0 := fedtest.Issue(t, nil, nil, 1)
+	dest1 := fedtest.Dest(t)
+	transfer := fedtest.Transfer(t, fedtest.StateOut(issueTx, 0), dest0, dest1)
+
+	if err := pool.Add(ctx, issueTx, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{}); err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if err := pool.Add(ctx, transfer, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{}); err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	// A third, unrelated tx pushes the pool over MaxSize. Evicting
+	// the oldest entry (issueTx) must also evict transfer, which
+	// depends on it, rather than leaving a dangling spend behind.
+	other, _, _ := fedtest.Issue(t, nil, nil, 1)
+	if err := pool.Add(ctx, other, map[bc.AssetID]uint64{}, map[bc.AssetID]uint64{}); err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	if pool.Get(issueTx.Hash) != nil {
+		t.Fatal("issueTx still pending over MaxSize, want evicted")
+	}
+	if pool.Get(transfer.Hash) != nil {
+		t.Fatal("transfer still pending after its dependency was evicted, want evicted too")
+	}
+	if pool.Get(other.Hash) == nil {
+		t.Fatal("newest tx missing, want pending")
+	}
+}
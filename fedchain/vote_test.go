@@ -0,0 +1,282 @@
+package fedchain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"chain/errors"
+	"chain/fedchain/bc"
+	"chain/fedchain/fedtest"
+	"chain/fedchain/memstore"
+	"chain/testutil"
+)
+
+type votedTestStore struct {
+	memstore.MemStore
+	f func(map[bc.AssetID]uint64)
+}
+
+func (s *votedTestStore) ApplyTx(ctx context.Context, tx *bc.Tx, issued, voted map[bc.AssetID]uint64) error {
+	err := s.MemStore.ApplyTx(ctx, tx, issued, voted)
+	if s.f != nil {
+		s.f(voted)
+	}
+	return err
+}
+
+// confirm adds tx to fc, assembles a block containing just it, and
+// lands that block, so that tx's UTXOs are available from the
+// store rather than only from the pool.
+func confirm(t *testing.T, ctx context.Context, fc *FC, tx *bc.Tx) {
+	err := fc.AddTx(ctx, tx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	block, _, err := fc.GenerateBlock(ctx, time.Now())
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = fc.AddBlock(ctx, block)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+}
+
+func TestAddTxVoted(t *testing.T) {
+	ctx := context.Background()
+
+	asset0 := fedtest.Asset(t)
+	asset1 := fedtest.Asset(t)
+	dest0 := fedtest.Dest(t)
+	dest1 := fedtest.Dest(t)
+	candidate := []byte("candidate-a")
+	pastDeadline := uint64(time.Now().Add(-time.Hour).Unix())
+
+	issue0, _, _ := fedtest.Issue(t, asset0, dest0, 10)
+	issue1, _, _ := fedtest.Issue(t, asset1, dest0, 5)
+
+	store := &votedTestStore{MemStore: *memstore.New()}
+	fc, err := New(ctx, store, nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	confirm(t, ctx, fc, issue0)
+	confirm(t, ctx, fc, issue1)
+
+	multiVoteData := &bc.TxData{
+		Version: bc.CurrentTransactionVersion,
+		Inputs: []*bc.TxInput{
+			{
+				Previous: bc.Outpoint{Hash: issue0.Hash, Index: 0},
+				Vote:     &bc.VoteInput{Candidate: candidate, Deadline: pastDeadline},
+			},
+			{
+				Previous: bc.Outpoint{Hash: issue1.Hash, Index: 0},
+				Vote:     &bc.VoteInput{Candidate: candidate, Deadline: pastDeadline},
+			},
+		},
+		Outputs: []*bc.TxOutput{
+			{
+				Script:      dest0.PKScript,
+				AssetAmount: bc.AssetAmount{AssetID: asset0.AssetID, Amount: 10},
+				Vote:        &bc.VoteOutput{Vote: candidate, Deadline: pastDeadline},
+			},
+			{
+				Script:      dest0.PKScript,
+				AssetAmount: bc.AssetAmount{AssetID: asset1.AssetID, Amount: 5},
+				Vote:        &bc.VoteOutput{Vote: candidate, Deadline: pastDeadline},
+			},
+		},
+	}
+	dest0.Sign(t, multiVoteData, 0, issue0.Outputs[0].AssetAmount)
+	dest0.Sign(t, multiVoteData, 1, issue1.Outputs[0].AssetAmount)
+	multiVote := bc.NewTx(*multiVoteData)
+
+	unvote := fedtest.Unvote(t, fedtest.StateOut(multiVote, 0), dest0, dest1)
+
+	cases := []struct {
+		tx   *bc.Tx
+		want map[bc.AssetID]uint64
+	}{
+		{tx: multiVote, want: map[bc.AssetID]uint64{asset0.AssetID: 10, asset1.AssetID: 5}},
+		{tx: unvote, want: map[bc.AssetID]uint64{}},
+	}
+	for _, c := range cases {
+		err := fc.AddTx(ctx, c.tx)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		store.f = func(got map[bc.AssetID]uint64) {
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got voted = %+v want %+v", got, c.want)
+			}
+		}
+		block, _, err := fc.GenerateBlock(ctx, time.Now())
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		err = fc.AddBlock(ctx, block)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+	}
+}
+
+// TestAddTxVotedMixed checks that an issuance input and a
+// vote-casting input in the same transaction are accounted for
+// independently: issuing one asset doesn't get confused with
+// voting another.
+func TestAddTxVotedMixed(t *testing.T) {
+	ctx := context.Background()
+
+	asset0 := fedtest.Asset(t)
+	asset1 := fedtest.Asset(t)
+	dest0 := fedtest.Dest(t)
+	candidate := []byte("candidate-b")
+	pastDeadline := uint64(time.Now().Add(-time.Hour).Unix())
+
+	issue0, _, _ := fedtest.Issue(t, asset0, dest0, 3)
+
+	store := &votedTestStore{MemStore: *memstore.New()}
+	fc, err := New(ctx, store, nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	confirm(t, ctx, fc, issue0)
+
+	mixedData := &bc.TxData{
+		Version: bc.CurrentTransactionVersion,
+		Inputs: []*bc.TxInput{
+			{Previous: bc.Outpoint{Index: bc.InvalidOutputIndex}},
+			{
+				Previous: bc.Outpoint{Hash: issue0.Hash, Index: 0},
+				Vote:     &bc.VoteInput{Candidate: candidate, Deadline: pastDeadline},
+			},
+		},
+		Outputs: []*bc.TxOutput{
+			{
+				Script:      dest0.PKScript,
+				AssetAmount: bc.AssetAmount{AssetID: asset1.AssetID, Amount: 9},
+			},
+			{
+				Script:      dest0.PKScript,
+				AssetAmount: bc.AssetAmount{AssetID: asset0.AssetID, Amount: 3},
+				Vote:        &bc.VoteOutput{Vote: candidate, Deadline: pastDeadline},
+			},
+		},
+	}
+	asset1.Sign(t, mixedData, 0, bc.AssetAmount{})
+	dest0.Sign(t, mixedData, 1, issue0.Outputs[0].AssetAmount)
+	mixed := bc.NewTx(*mixedData)
+
+	var gotVoted map[bc.AssetID]uint64
+	store.f = func(voted map[bc.AssetID]uint64) { gotVoted = voted }
+
+	err = fc.AddTx(ctx, mixed)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	block, _, err := fc.GenerateBlock(ctx, time.Now())
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = fc.AddBlock(ctx, block)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	wantVoted := map[bc.AssetID]uint64{asset0.AssetID: 3}
+	if !reflect.DeepEqual(gotVoted, wantVoted) {
+		t.Errorf("got voted = %+v want %+v", gotVoted, wantVoted)
+	}
+}
+
+// TestAddTxVoteAmountMismatch checks that a vote output which
+// doesn't preserve the spent input's amount is rejected before it
+// ever reaches the pool.
+func TestAddTxVoteAmountMismatch(t *testing.T) {
+	ctx, fc := newContextFC(t)
+
+	asset0 := fedtest.Asset(t)
+	dest0 := fedtest.Dest(t)
+	candidate := []byte("candidate-c")
+	deadline := uint64(time.Now().Add(time.Hour).Unix())
+
+	issue0, _, _ := fedtest.Issue(t, asset0, dest0, 4)
+
+	err := fc.AddTx(ctx, issue0)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	badVoteData := &bc.TxData{
+		Version: bc.CurrentTransactionVersion,
+		Inputs: []*bc.TxInput{
+			{
+				Previous: bc.Outpoint{Hash: issue0.Hash, Index: 0},
+				Vote:     &bc.VoteInput{Candidate: candidate, Deadline: deadline},
+			},
+		},
+		Outputs: []*bc.TxOutput{
+			{
+				Script:      dest0.PKScript,
+				AssetAmount: bc.AssetAmount{AssetID: asset0.AssetID, Amount: 3}, // should be 4
+				Vote:        &bc.VoteOutput{Vote: candidate, Deadline: deadline},
+			},
+		},
+	}
+	dest0.Sign(t, badVoteData, 0, issue0.Outputs[0].AssetAmount)
+	badVote := bc.NewTx(*badVoteData)
+
+	err = fc.AddTx(ctx, badVote)
+	if errors.Root(err) != ErrTxRejected {
+		t.Fatalf("got err = %v, want ErrTxRejected", err)
+	}
+}
+
+// TestAddTxVoteForgedCandidate checks that a vote output recording
+// a candidate or deadline other than the casting input's is
+// rejected, even though it preserves the spent amount.
+func TestAddTxVoteForgedCandidate(t *testing.T) {
+	ctx, fc := newContextFC(t)
+
+	asset0 := fedtest.Asset(t)
+	dest0 := fedtest.Dest(t)
+	candidate := []byte("candidate-d")
+	forgedCandidate := []byte("candidate-e")
+	deadline := uint64(time.Now().Add(time.Hour).Unix())
+
+	issue0, _, _ := fedtest.Issue(t, asset0, dest0, 4)
+
+	err := fc.AddTx(ctx, issue0)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	forgedVoteData := &bc.TxData{
+		Version: bc.CurrentTransactionVersion,
+		Inputs: []*bc.TxInput{
+			{
+				Previous: bc.Outpoint{Hash: issue0.Hash, Index: 0},
+				Vote:     &bc.VoteInput{Candidate: candidate, Deadline: deadline},
+			},
+		},
+		Outputs: []*bc.TxOutput{
+			{
+				Script:      dest0.PKScript,
+				AssetAmount: bc.AssetAmount{AssetID: asset0.AssetID, Amount: 4},
+				Vote:        &bc.VoteOutput{Vote: forgedCandidate, Deadline: deadline}, // should be candidate
+			},
+		},
+	}
+	dest0.Sign(t, forgedVoteData, 0, issue0.Outputs[0].AssetAmount)
+	forgedVote := bc.NewTx(*forgedVoteData)
+
+	err = fc.AddTx(ctx, forgedVote)
+	if errors.Root(err) != ErrTxRejected {
+		t.Fatalf("got err = %v, want ErrTxRejected", err)
+	}
+}
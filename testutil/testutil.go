@@ -0,0 +1,10 @@
+// Package testutil collects small helpers shared by test files
+// throughout the tree.
+package testutil
+
+import "testing"
+
+// FatalErr fails the test with a message that includes err.
+func FatalErr(t *testing.T, err error) {
+	t.Fatalf("unexpected error: %s", err)
+}